@@ -1,78 +1,179 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go-api/internal/auth"
 	"go-api/internal/config"
-	handlerAdmin "go-api/internal/handlers/admin"
-	handlerAds "go-api/internal/handlers/ads"
-	handlerAuth "go-api/internal/handlers/auth"
-	handlerInfo "go-api/internal/handlers/info"
-	handlerSys "go-api/internal/handlers/sys"
-	handlerWork "go-api/internal/handlers/worker"
+	"go-api/internal/jobs"
+	"go-api/internal/metrics"
+	"go-api/internal/moderation"
+	"go-api/internal/ratelimit"
 	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	_ "time/tzdata" // часовые пояса расписаний мастеров без системной базы tzdata
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"gopkg.in/yaml.v3"
 )
 
 func main() {
+	// подкоманда: go-api config print
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
 	cfg := config.MustLoad() //init config cleanenv
 
 	logger := setupLogger(cfg.Env) //init logger slog
 	logger.Info("starting", slog.String("env", cfg.Env))
 
-	store, err := storage.NewDB(cfg.DB, logger) // init storage postgresql
-	auth.Init(cfg.JWT.SecretKey)                //init secret key
+	if err := run(cfg, logger); err != nil {
+		logger.Error("server stopped with error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger.Info("server stopped")
+}
+
+// run запускает сервер и блокирует до SIGINT/SIGTERM. Остановка идёт в обратном порядке:
+// HTTP-сервер дорабатывает текущие запросы, затем фоновые задачи, трассировка и последней - БД
+func run(cfg *config.Config, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing) // init OpenTelemetry
 	if err != nil {
-		logger.Error("DB init failed", slog.String("error", err.Error()))
-		os.Exit(1)
+		return fmt.Errorf("tracing init failed: %w", err)
+	}
+
+	store, err := storage.NewDB(cfg.DB, logger) // init storage postgresql
+	if err != nil {
+		shutdownTracing(context.Background())
+		return fmt.Errorf("DB init failed: %w", err)
+	}
+
+	auth.Init(cfg.JWT.SecretKey)    //init secret key
+	moderation.Init(cfg.Moderation) //init auto-moderation rules
+	ratelimit.Init(cfg.RateLimit)   //init rate limits
+	storage.InitAds(cfg.Ads)        //init ad publication period
+
+	if sqlDB, err := store.DB().DB(); err == nil {
+		metrics.RegisterDB(sqlDB) // статистика пула соединений в /metrics
+	}
+
+	// фоновые задачи (останавливаются в обратном порядке)
+	workers := []*jobs.Worker{
+		jobs.Start("retention", func(ctx context.Context) { jobs.RunRetention(ctx, store.DB(), cfg.Retention, logger) }),
+		jobs.Start("analytics", func(ctx context.Context) { jobs.RunAnalytics(ctx, store.DB(), cfg.Analytics, logger) }),
+		jobs.Start("ads", func(ctx context.Context) { jobs.RunAdLifecycle(ctx, store.DB(), cfg.Ads, logger) }),
 	}
-	defer store.Close()
 
-	r := chi.NewRouter() // init router chi
+	r := newRouter(cfg, store.DB(), logger)
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
+	srv := &http.Server{
+		Addr:              cfg.HTTPServer.Address,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.HTTPServer.TimeOut,
+		ReadTimeout:       cfg.HTTPServer.TimeOut,
+		WriteTimeout:      cfg.HTTPServer.TimeOut,
+		IdleTimeout:       cfg.HTTPServer.IdleTimeOut,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	tls := cfg.HTTPServer.TLS.CertFile != "" && cfg.HTTPServer.TLS.KeyFile != ""
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server started", slog.String("address", srv.Addr), slog.Bool("tls", tls))
 
-	handlerAuth.SetupRoutes(store.DB(), logger, r)
-	handlerSys.SetupRoutes(store.DB(), logger, r)
-	handlerWork.SetupRoutes(store.DB(), logger, r)
-	handlerInfo.SetupRoutes(store.DB(), logger, r)
-	handlerAds.SetupRoutes(store.DB(), logger, r)
-	handlerAdmin.SetupRoutes(store.DB(), logger, r) // Админ-панель
+		var err error
+		if tls {
+			err = srv.ListenAndServeTLS(cfg.HTTPServer.TLS.CertFile, cfg.HTTPServer.TLS.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+	case err := <-serveErr:
+		runErr = fmt.Errorf("http server failed: %w", err)
+	}
 
-	logger.Info("server started", slog.String("port", ":8080"))
-	http.ListenAndServe(":8080", r)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	// 1. Перестаём принимать соединения и ждём текущие запросы
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http server shutdown failed", slog.String("error", err.Error()))
+	}
+
+	// 2. Фоновые задачи - в обратном порядке запуска
+	for i := len(workers) - 1; i >= 0; i-- {
+		if err := workers[i].Stop(shutdownCtx); err != nil {
+			logger.Error("background job stop failed", slog.String("error", err.Error()))
+		}
+	}
+
+	// 3. Дописываем накопленные спаны
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("tracing shutdown failed", slog.String("error", err.Error()))
+	}
+
+	// 4. БД закрывается последней
+	if err := store.Close(); err != nil {
+		logger.Error("DB close failed", slog.String("error", err.Error()))
+	}
+
+	return runErr
 }
 
-// константы логгера
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
-)
+// runCommand выполняет служебные подкоманды вместо запуска сервера
+func runCommand(args []string) error {
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		// эффективный конфиг (файл + окружение) без секретов
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(cfg.Redacted())
+	}
+	return fmt.Errorf("unknown command %q, usage: %s [config print]", strings.Join(args, " "), os.Args[0])
+}
 
-// создание логгера
+// создание логгера (неизвестное окружение отсекается валидацией конфига, но на всякий случай - как prod)
 func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
 	switch env {
-	case envLocal:
-		log = slog.New(
+	case config.EnvLocal:
+		return slog.New(
 			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
 		)
-	case envDev:
-		log = slog.New(
+	case config.EnvDev:
+		return slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
 		)
-	case envProd:
-		log = slog.New(
+	default:
+		return slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
 	}
-	return log
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"go-api/internal/config"
+	handlerAdmin "go-api/internal/handlers/admin"
+	handlerAds "go-api/internal/handlers/ads"
+	handlerAuth "go-api/internal/handlers/auth"
+	handlerDocs "go-api/internal/handlers/docs"
+	handlerInfo "go-api/internal/handlers/info"
+	handlerNotifications "go-api/internal/handlers/notifications"
+	handlerReports "go-api/internal/handlers/reports"
+	handlerSys "go-api/internal/handlers/sys"
+	handlerWork "go-api/internal/handlers/worker"
+	"go-api/internal/metrics"
+	appMiddleware "go-api/internal/middleware"
+	"go-api/internal/ratelimit"
+	"go-api/internal/tracing"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+// newRouter собирает все маршруты API. Каждый маршрут должен быть описан
+// в спецификации OpenAPI (handlers/docs/openapi.json), это проверяет router_test.go
+func newRouter(cfg *config.Config, db *gorm.DB, logger *slog.Logger) chi.Router {
+	r := chi.NewRouter() // init router chi
+
+	if cfg.RateLimit.TrustProxy {
+		r.Use(middleware.RealIP) // IP клиента из X-Forwarded-For / X-Real-IP
+	}
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(appMiddleware.RateLimit(ratelimit.GroupGlobal, logger))
+
+	// служебные маршруты и документация не версионируются
+	handlerSys.SetupRoutes(db, logger, r, cfg.DB.PingTimeout)
+	handlerDocs.SetupRoutes(logger, r) // OpenAPI и документация
+
+	v1 := newV1Router(db, logger, cfg.HTTPServer.ExportTimeout)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(appMiddleware.APIVersion(appMiddleware.APIv1))
+		r.Mount("/", v1)
+	})
+
+	// старые адреса без префикса - тот же роутер v1 с заголовками устаревания
+	if !cfg.API.DisableLegacy {
+		deprecatedAt, sunset, _ := cfg.API.LegacyDates() // даты проверены в config.Validate
+		r.Group(func(r chi.Router) {
+			r.Use(appMiddleware.LegacyAlias(appMiddleware.APIv1, deprecatedAt, sunset, logger))
+			r.Mount("/", v1)
+		})
+	}
+
+	return r
+}
+
+// newV1Router - маршруты версии v1. Следующая версия получает свой роутер: пакеты, у которых
+// формат ответов не изменился, подключаются тем же SetupRoutes, а изменившиеся обработчики
+// различают версию через middleware.Version и меняют только DTO - запросы к БД остаются в storage
+func newV1Router(db *gorm.DB, logger *slog.Logger, exportTimeout time.Duration) chi.Router {
+	r := chi.NewRouter()
+
+	handlerAuth.SetupRoutes(db, logger, r)
+	handlerWork.SetupRoutes(db, logger, r)
+	handlerInfo.SetupRoutes(db, logger, r)
+	handlerAds.SetupRoutes(db, logger, r)
+	handlerReports.SetupRoutes(db, logger, r)
+	handlerNotifications.SetupRoutes(db, logger, r)
+	handlerAdmin.SetupRoutes(db, logger, r, exportTimeout) // Админ-панель
+
+	return r
+}
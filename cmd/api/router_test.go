@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"go-api/internal/config"
+	handlerDocs "go-api/internal/handlers/docs"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestOpenAPIMatchesRoutes сверяет зарегистрированные маршруты со спецификацией OpenAPI:
+// новый маршрут без описания или описание удалённого маршрута роняют тест.
+// Старые адреса без /api/v1 в спецификации не описываются - это псевдонимы маршрутов v1
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.API.LegacyDeprecatedAt = "2026-10-19"
+	r := newRouter(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	routes := map[string]bool{}
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.ReplaceAll(route, "/*/", "/") // роутер v1 смонтирован в "/api/v1/*"
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/") // chi отдаёт корень смонтированного роутера как "/ads/"
+		}
+		routes[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(handlerDocs.Spec(), &spec); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("openapi version = %q, want 3.x", spec.OpenAPI)
+	}
+
+	documented := map[string]bool{}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for _, route := range sorted(routes) {
+		method, path, _ := strings.Cut(route, " ")
+		if !documented[route] && !documented[method+" /api/v1"+path] {
+			t.Errorf("route %s is not described in openapi.json", route)
+		}
+	}
+	for _, op := range sorted(documented) {
+		if !routes[op] {
+			t.Errorf("openapi.json describes %s, but there is no such route", op)
+		}
+	}
+}
+
+func sorted(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-api/internal/models"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+// Record добавляет запись в журнал аудита. before/after - состояние объекта
+// до и после действия (структура или map); в журнал попадают только изменившиеся поля.
+// Для создания before = nil, для удаления after = nil
+func Record(db *gorm.DB, r *http.Request, action, targetType string, targetID interface{}, before, after interface{}) error {
+	actorID, _ := r.Context().Value("user_id").(uint)
+
+	beforeDiff, afterDiff, err := diff(before, after)
+	if err != nil {
+		return fmt.Errorf("audit diff failed: %w", err)
+	}
+
+	entry := models.AuditLog{
+		CreatedAt:  time.Now(),
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     beforeDiff,
+		After:      afterDiff,
+		RequestID:  middleware.GetReqID(r.Context()),
+		IP:         clientIP(r),
+	}
+
+	return db.Create(&entry).Error
+}
+
+func diff(before, after interface{}) (string, string, error) {
+	b, err := toMap(before)
+	if err != nil {
+		return "", "", err
+	}
+	a, err := toMap(after)
+	if err != nil {
+		return "", "", err
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for k, v := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(v, av) {
+			changedBefore[k] = v
+		}
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || !reflect.DeepEqual(v, bv) {
+			changedAfter[k] = v
+		}
+	}
+
+	return encode(changedBefore, before == nil), encode(changedAfter, after == nil), nil
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func encode(m map[string]interface{}, empty bool) string {
+	if empty {
+		return ""
+	}
+	data, _ := json.Marshal(m)
+	return string(data)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
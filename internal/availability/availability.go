@@ -0,0 +1,147 @@
+package availability
+
+import (
+	"go-api/internal/models"
+	"sort"
+	"time"
+)
+
+// Расчёт свободного времени мастера: недельное расписание минус исключения и брони
+
+// Interval - промежуток времени [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ParseClock - время HH:MM в минутах от полуночи
+func ParseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil || len(s) != 5 {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// isoWeekday - 1 - понедельник ... 7 - воскресенье
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// Working - рабочие интервалы по недельному расписанию в [from, to) без дней-исключений.
+// Дни и время расписания считаются в часовом поясе loc
+func Working(weekly []models.WorkerAvailability, exceptions []models.AvailabilityException, from, to time.Time, loc *time.Location) []Interval {
+	var result []Interval
+	start := from.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if dayExcluded(exceptions, day) {
+			continue
+		}
+		for _, slot := range weekly {
+			if slot.Weekday != isoWeekday(day) {
+				continue
+			}
+			startMin, ok1 := ParseClock(slot.Start)
+			endMin, ok2 := ParseClock(slot.End)
+			if !ok1 || !ok2 || endMin <= startMin {
+				continue
+			}
+			// time.Date, а не Add: корректно при переходе на летнее время
+			iv := Interval{
+				Start: time.Date(day.Year(), day.Month(), day.Day(), startMin/60, startMin%60, 0, 0, loc),
+				End:   time.Date(day.Year(), day.Month(), day.Day(), endMin/60, endMin%60, 0, 0, loc),
+			}
+			if iv.Start.Before(from) {
+				iv.Start = from.In(loc)
+			}
+			if iv.End.After(to) {
+				iv.End = to.In(loc)
+			}
+			if iv.Start.Before(iv.End) {
+				result = append(result, iv)
+			}
+		}
+	}
+	return merge(result)
+}
+
+// dayExcluded - день попадает в одно из исключений (даты исключений включительно)
+func dayExcluded(exceptions []models.AvailabilityException, day time.Time) bool {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for _, e := range exceptions {
+		if !date.Before(e.DateFrom.UTC()) && !date.After(e.DateTo.UTC()) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge сортирует интервалы и склеивает пересекающиеся и смежные
+func merge(intervals []Interval) []Interval {
+	if len(intervals) == 0 {
+		return intervals
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start.Before(intervals[j].Start) })
+	result := []Interval{intervals[0]}
+	for _, iv := range intervals[1:] {
+		last := &result[len(result)-1]
+		if !iv.Start.After(last.End) {
+			if iv.End.After(last.End) {
+				last.End = iv.End
+			}
+			continue
+		}
+		result = append(result, iv)
+	}
+	return result
+}
+
+// Subtract - части интервалов free, не занятые busy
+func Subtract(free, busy []Interval) []Interval {
+	busy = merge(append([]Interval(nil), busy...))
+	var result []Interval
+	for _, iv := range free {
+		cur := iv
+		for _, b := range busy {
+			if !b.End.After(cur.Start) || !b.Start.Before(cur.End) {
+				continue
+			}
+			if b.Start.After(cur.Start) {
+				result = append(result, Interval{Start: cur.Start, End: b.Start})
+			}
+			cur.Start = b.End
+			if !cur.Start.Before(cur.End) {
+				break
+			}
+		}
+		if cur.Start.Before(cur.End) {
+			result = append(result, cur)
+		}
+	}
+	return result
+}
+
+// AtLeast - интервалы не короче d
+func AtLeast(intervals []Interval, d time.Duration) []Interval {
+	var result []Interval
+	for _, iv := range intervals {
+		if iv.End.Sub(iv.Start) >= d {
+			result = append(result, iv)
+		}
+	}
+	return result
+}
+
+// Covers - slot целиком внутри одного из интервалов
+func Covers(intervals []Interval, slot Interval) bool {
+	for _, iv := range intervals {
+		if !slot.Start.Before(iv.Start) && !slot.End.After(iv.End) {
+			return true
+		}
+	}
+	return false
+}
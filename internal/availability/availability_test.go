@@ -0,0 +1,226 @@
+package availability
+
+import (
+	"go-api/internal/models"
+	"testing"
+	"time"
+	_ "time/tzdata" // часовые пояса не зависят от системы, где запускаются тесты
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+// at - момент в loc: день месяца 2026 года и время HH:MM
+func at(loc *time.Location, month time.Month, day, hour, min int) time.Time {
+	return time.Date(2026, month, day, hour, min, 0, 0, loc)
+}
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func assertIntervals(t *testing.T, got, want []Interval) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d intervals %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("interval %d: got [%s, %s), want [%s, %s)", i,
+				got[i].Start.UTC(), got[i].End.UTC(), want[i].Start.UTC(), want[i].End.UTC())
+		}
+	}
+}
+
+func TestWorking(t *testing.T) {
+	msk := mustLocation(t, "Europe/Moscow")
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	// 2026-10-19 - понедельник; 2026-03-29 и 2026-10-25 - воскресенья перехода на летнее и зимнее время в Берлине
+	tests := []struct {
+		name       string
+		weekly     []models.WorkerAvailability
+		exceptions []models.AvailabilityException
+		from, to   time.Time
+		loc        *time.Location
+		want       []Interval
+	}{
+		{
+			name:   "пересекающиеся слоты дня склеиваются",
+			weekly: []models.WorkerAvailability{{Weekday: 1, Start: "11:00", End: "14:00"}, {Weekday: 1, Start: "09:00", End: "12:00"}},
+			from:   at(msk, 10, 19, 0, 0),
+			to:     at(msk, 10, 20, 0, 0),
+			loc:    msk,
+			want:   []Interval{{at(msk, 10, 19, 9, 0), at(msk, 10, 19, 14, 0)}},
+		},
+		{
+			name:   "интервал обрезается границами периода",
+			weekly: []models.WorkerAvailability{{Weekday: 1, Start: "09:00", End: "18:00"}},
+			from:   at(msk, 10, 19, 10, 0),
+			to:     at(msk, 10, 19, 11, 30),
+			loc:    msk,
+			want:   []Interval{{at(msk, 10, 19, 10, 0), at(msk, 10, 19, 11, 30)}},
+		},
+		{
+			name:   "дни недели и время - в часовом поясе мастера",
+			weekly: []models.WorkerAvailability{{Weekday: 1, Start: "09:00", End: "10:00"}},
+			from:   time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC),
+			loc:    msk,
+			want:   []Interval{{time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:   "некорректные слоты пропускаются",
+			weekly: []models.WorkerAvailability{{Weekday: 1, Start: "12:00", End: "12:00"}, {Weekday: 1, Start: "9:00", End: "10:00"}},
+			from:   at(msk, 10, 19, 0, 0),
+			to:     at(msk, 10, 20, 0, 0),
+			loc:    msk,
+			want:   nil,
+		},
+		{
+			name: "многодневное исключение убирает все свои дни",
+			weekly: []models.WorkerAvailability{
+				{Weekday: 1, Start: "09:00", End: "17:00"},
+				{Weekday: 2, Start: "09:00", End: "17:00"},
+				{Weekday: 3, Start: "09:00", End: "17:00"},
+				{Weekday: 4, Start: "09:00", End: "17:00"},
+			},
+			exceptions: []models.AvailabilityException{{DateFrom: date(10, 20), DateTo: date(10, 21)}},
+			from:       at(msk, 10, 19, 0, 0),
+			to:         at(msk, 10, 23, 0, 0),
+			loc:        msk,
+			want: []Interval{
+				{at(msk, 10, 19, 9, 0), at(msk, 10, 19, 17, 0)},
+				{at(msk, 10, 22, 9, 0), at(msk, 10, 22, 17, 0)},
+			},
+		},
+		{
+			name:       "день исключения - по часовому поясу мастера",
+			weekly:     []models.WorkerAvailability{{Weekday: 1, Start: "01:00", End: "02:00"}},
+			exceptions: []models.AvailabilityException{{DateFrom: date(10, 19), DateTo: date(10, 19)}},
+			from:       time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			to:         time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC),
+			loc:        msk,
+			want:       nil,
+		},
+		{
+			name:   "переход на летнее время: в рабочем дне на час меньше",
+			weekly: []models.WorkerAvailability{{Weekday: 7, Start: "01:00", End: "05:00"}},
+			from:   at(berlin, 3, 29, 0, 0),
+			to:     at(berlin, 3, 30, 0, 0),
+			loc:    berlin,
+			want:   []Interval{{time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 29, 3, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:   "переход на зимнее время: в рабочем дне на час больше",
+			weekly: []models.WorkerAvailability{{Weekday: 7, Start: "01:00", End: "05:00"}},
+			from:   at(berlin, 10, 25, 0, 0),
+			to:     at(berlin, 10, 26, 0, 0),
+			loc:    berlin,
+			want:   []Interval{{time.Date(2026, 10, 24, 23, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 4, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:   "после перехода время расписания не сдвигается",
+			weekly: []models.WorkerAvailability{{Weekday: 1, Start: "09:00", End: "10:00"}},
+			from:   at(berlin, 3, 23, 0, 0),
+			to:     at(berlin, 3, 31, 0, 0),
+			loc:    berlin,
+			want: []Interval{
+				{time.Date(2026, 3, 23, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 23, 9, 0, 0, 0, time.UTC)},
+				{time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC), time.Date(2026, 3, 30, 8, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertIntervals(t, Working(tt.weekly, tt.exceptions, tt.from, tt.to, tt.loc), tt.want)
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	h := func(hour int) time.Time { return at(time.UTC, 10, 19, hour, 0) }
+
+	tests := []struct {
+		name      string
+		intervals []Interval
+		want      []Interval
+	}{
+		{"пусто", nil, nil},
+		{"несортированные без пересечений", []Interval{{h(14), h(15)}, {h(9), h(10)}}, []Interval{{h(9), h(10)}, {h(14), h(15)}}},
+		{"смежные склеиваются", []Interval{{h(9), h(10)}, {h(10), h(11)}}, []Interval{{h(9), h(11)}}},
+		{"вложенный поглощается", []Interval{{h(9), h(17)}, {h(10), h(11)}}, []Interval{{h(9), h(17)}}},
+		{"цепочка пересечений", []Interval{{h(12), h(14)}, {h(9), h(11)}, {h(10), h(13)}}, []Interval{{h(9), h(14)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertIntervals(t, merge(append([]Interval(nil), tt.intervals...)), tt.want)
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	h := func(hour int) time.Time { return at(time.UTC, 10, 19, hour, 0) }
+
+	tests := []struct {
+		name       string
+		free, busy []Interval
+		want       []Interval
+	}{
+		{"без броней", []Interval{{h(9), h(17)}}, nil, []Interval{{h(9), h(17)}}},
+		{"бронь делит свободный интервал", []Interval{{h(9), h(17)}}, []Interval{{h(11), h(13)}}, []Interval{{h(9), h(11)}, {h(13), h(17)}}},
+		{"бронь у начала", []Interval{{h(9), h(17)}}, []Interval{{h(8), h(10)}}, []Interval{{h(10), h(17)}}},
+		{"бронь у конца", []Interval{{h(9), h(17)}}, []Interval{{h(16), h(18)}}, []Interval{{h(9), h(16)}}},
+		{"бронь занимает весь интервал", []Interval{{h(9), h(12)}, {h(14), h(17)}}, []Interval{{h(8), h(12)}}, []Interval{{h(14), h(17)}}},
+		{"бронь вне интервала", []Interval{{h(9), h(12)}}, []Interval{{h(12), h(13)}}, []Interval{{h(9), h(12)}}},
+		{
+			"несколько несортированных броней",
+			[]Interval{{h(9), h(17)}},
+			[]Interval{{h(15), h(16)}, {h(10), h(11)}, {h(10), h(12)}},
+			[]Interval{{h(9), h(10)}, {h(12), h(15)}, {h(16), h(17)}},
+		},
+		{"бронь через несколько интервалов", []Interval{{h(9), h(11)}, {h(12), h(14)}}, []Interval{{h(10), h(13)}}, []Interval{{h(9), h(10)}, {h(13), h(14)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			busy := append([]Interval(nil), tt.busy...)
+			assertIntervals(t, Subtract(tt.free, tt.busy), tt.want)
+			assertIntervals(t, tt.busy, busy) // брони вызывающего не сортируются на месте
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	h := func(hour int) time.Time { return at(time.UTC, 10, 19, hour, 0) }
+	free := []Interval{{h(9), h(12)}, {h(12), h(13)}, {h(14), h(17)}}
+
+	tests := []struct {
+		name string
+		slot Interval
+		want bool
+	}{
+		{"внутри интервала", Interval{h(10), h(11)}, true},
+		{"совпадает с интервалом", Interval{h(14), h(17)}, true},
+		{"выходит за конец", Interval{h(16), h(18)}, false},
+		{"попадает на перерыв", Interval{h(13), h(14)}, false},
+		{"через два несклеенных интервала", Interval{h(11), h(13)}, false},
+		{"вне расписания", Interval{h(18), h(19)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Covers(free, tt.slot); got != tt.want {
+				t.Errorf("Covers(%v) = %v, want %v", tt.slot, got, tt.want)
+			}
+		})
+	}
+}
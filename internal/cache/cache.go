@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ключи справочников. Инвалидация идёт по префиксу, поэтому варианты
+// ответа (язык, формат) хранятся под ключами вида "info:categories?lang=en"
+const (
+	Categories = "info:categories"
+	PriceUnits = "info:price_units"
+)
+
+// Entry - готовое тело ответа с валидаторами для условных запросов
+type Entry struct {
+	Body     []byte
+	ETag     string
+	Modified time.Time
+}
+
+var (
+	mu         sync.RWMutex
+	entries    = map[string]Entry{}
+	generation uint64 // растёт при каждой инвалидации
+)
+
+// NewEntry считает ETag по содержимому. Last-Modified округляется до секунд, как в HTTP
+func NewEntry(body []byte, modified time.Time) Entry {
+	sum := sha256.Sum256(body)
+	return Entry{
+		Body:     body,
+		ETag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Modified: modified.UTC().Truncate(time.Second),
+	}
+}
+
+// Load возвращает запись из кэша или строит её через load и сохраняет.
+// Если во время построения кэш инвалидировали, результат не сохраняется
+func Load(key string, load func() ([]byte, error)) (Entry, error) {
+	mu.RLock()
+	e, ok := entries[key]
+	gen := generation
+	mu.RUnlock()
+	if ok {
+		return e, nil
+	}
+
+	body, err := load()
+	if err != nil {
+		return Entry{}, err
+	}
+	e = NewEntry(body, time.Now())
+
+	mu.Lock()
+	if gen == generation {
+		entries[key] = e
+	}
+	mu.Unlock()
+
+	return e, nil
+}
+
+// Invalidate удаляет все записи с ключом, начинающимся с prefix
+func Invalidate(prefixes ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	generation++
+	for key := range entries {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				delete(entries, key)
+				break
+			}
+		}
+	}
+}
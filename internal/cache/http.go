@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Значения Cache-Control
+const (
+	// Справочники меняются редко: клиент может не спрашивать сервер 5 минут
+	ControlReference = "public, max-age=300"
+	// Публичные карточки можно хранить, но перед использованием нужно перепроверить (304)
+	ControlRevalidate = "public, no-cache"
+)
+
+// Write отдаёт запись с ETag, Last-Modified и Cache-Control или 304,
+// если клиент прислал актуальные If-None-Match / If-Modified-Since
+func Write(w http.ResponseWriter, r *http.Request, e Entry, cacheControl string) {
+	h := w.Header()
+	h.Set("ETag", e.ETag)
+	if !e.Modified.IsZero() {
+		h.Set("Last-Modified", e.Modified.Format(http.TimeFormat))
+	}
+	h.Set("Cache-Control", cacheControl)
+
+	if notModified(r, e) {
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(e.Body)
+}
+
+// WriteJSON кодирует v и отдаёт через Write (для ответов, которые не хранятся в кэше)
+func WriteJSON(w http.ResponseWriter, r *http.Request, v interface{}, modified time.Time, cacheControl string) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	Write(w, r, NewEntry(append(body, '\n'), modified), cacheControl)
+	return nil
+}
+
+// If-None-Match важнее If-Modified-Since (RFC 9110, 13.2.2)
+func notModified(r *http.Request, e Entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == e.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !e.Modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !e.Modified.After(t)
+	}
+	return false
+}
+
+// VersionETag - ETag записи с полем version (объявления, профили мастеров)
+func VersionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// RequireIfMatch разбирает If-Match для изменения записи с версией. anyVersion=true для "*"
+// (перезаписать любую версию). Без заголовка - ответ 428, с нечисловым ETag - 400
+func RequireIfMatch(w http.ResponseWriter, r *http.Request) (version uint, anyVersion bool, ok bool) {
+	if strings.TrimSpace(r.Header.Get("If-Match")) == "" {
+		writeError(w, http.StatusPreconditionRequired, "If-Match header with the current ETag is required")
+		return 0, false, false
+	}
+	return OptionalIfMatch(w, r)
+}
+
+// OptionalIfMatch - как RequireIfMatch, но без заголовка изменение разрешено (anyVersion=true)
+func OptionalIfMatch(w http.ResponseWriter, r *http.Request) (version uint, anyVersion bool, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true, true
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	v, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "If-Match must be a single ETag returned by GET")
+		return 0, false, false
+	}
+	return uint(v), false, true
+}
+
+// PreconditionFailed - ответ 412: запись изменилась после того, как клиент её прочитал.
+// В ETag и теле - текущая версия, чтобы клиент перечитал запись и повторил изменение
+func PreconditionFailed(w http.ResponseWriter, current uint) {
+	w.Header().Set("ETag", VersionETag(current))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "resource was modified, reload it and retry",
+		"version": current,
+	})
+}
+
+// StatusConflict - 409: из текущего статуса записи перейти в запрошенный нельзя
+// (или статус успели изменить). В ETag и теле - текущие статус и версия
+func StatusConflict(w http.ResponseWriter, entity, to, current string, version uint) {
+	w.Header().Set("ETag", VersionETag(version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   fmt.Sprintf("%s is %s and cannot become %s", entity, current, to),
+		"status":  current,
+		"version": version,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
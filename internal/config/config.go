@@ -1,66 +1,303 @@
 package config
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env        string `yaml:"env" env:"ENV" env-default:"local"` //Посмотреть про struct теги
-	DB         `yaml:"db"`
-	HTTPServer `yaml:"http_server"`
-	JWT        `yaml:"jwt"`
+	Env        string `yaml:"env" env:"ENV" env-default:"local"` // local, dev или prod
+	DB         `yaml:"db" env-prefix:"DB_"`
+	HTTPServer `yaml:"http_server" env-prefix:"HTTP_"`
+	JWT        `yaml:"jwt" env-prefix:"JWT_"`
+	Moderation `yaml:"moderation" env-prefix:"MODERATION_"`
+	Retention  `yaml:"retention" env-prefix:"RETENTION_"`
+	Analytics  `yaml:"analytics" env-prefix:"ANALYTICS_"`
+	Ads        `yaml:"ads" env-prefix:"ADS_"`
+	Tracing    `yaml:"tracing" env-prefix:"TRACING_"`
+	RateLimit  `yaml:"rate_limit" env-prefix:"RATE_LIMIT_"`
+	API        `yaml:"api" env-prefix:"API_"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	TimeOut     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeOut time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Address         string        `yaml:"address" env:"ADDRESS" env-default:"localhost:8080"`
+	TimeOut         time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"4s"`                // таймаут чтения запроса и записи ответа
+	ExportTimeout   time.Duration `yaml:"export_timeout" env:"EXPORT_TIMEOUT" env-default:"10m"` // таймаут записи выгрузок (CSV/XLSX отдаются потоком)
+	IdleTimeOut     time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" env-default:"60s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"15s"` // сколько ждать завершения запросов при остановке
+
+	// TLS включается, если заданы оба файла
+	TLS struct {
+		CertFile string `yaml:"cert_file" env:"CERT_FILE"`
+		KeyFile  string `yaml:"key_file" env:"KEY_FILE"`
+	} `yaml:"tls" env-prefix:"TLS_"`
 }
 
 type DB struct {
-	Host     string `yaml:"host" env-default:"localhost"`
-	Port     int    `yaml:"port" env-default:"5432"`
-	User     string `yaml:"user" env-default:"postgres"`
-	Password string `yaml:"password"`
-	DBname   string `yaml:"dbname" env-default:"handyman"`
-	SSLmode  string `yaml:"sslmode" env-default:"disable"`
+	Host     string `yaml:"host" env:"HOST" env-default:"localhost"`
+	Port     int    `yaml:"port" env:"PORT" env-default:"5432"`
+	User     string `yaml:"user" env:"USER" env-default:"postgres"`
+	Password string `yaml:"password" env:"PASSWORD"`
+	DBname   string `yaml:"dbname" env:"NAME" env-default:"handyman"`
+	SSLmode  string `yaml:"sslmode" env:"SSLMODE" env-default:"disable"`
 
 	// Пул соединений
-	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"10"`
-	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"100"`
-	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"1h"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS" env-default:"10"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MAX_OPEN_CONNS" env-default:"100"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"CONN_MAX_LIFETIME" env-default:"1h"`
 
 	// Таймауты
-	PingTimeout time.Duration `yaml:"ping_timeout" env-default:"5s"`
+	PingTimeout time.Duration `yaml:"ping_timeout" env:"PING_TIMEOUT" env-default:"5s"`
 }
 
 type JWT struct {
-	SecretKey string `yaml:"secret_key" env-default:"key"`
+	SecretKey string `yaml:"secret_key" env:"SECRET_KEY"` // в prod обязателен, не короче 32 символов
+}
+
+// Автомодерация объявлений и откликов.
+// Каждое правило имеет действие: approve, reject или review (на ручную проверку)
+type Moderation struct {
+	Disabled    bool `yaml:"disabled" env:"DISABLED"`         // выключить автомодерацию (всё уходит на ручную проверку)
+	AutoApprove bool `yaml:"auto_approve" env:"AUTO_APPROVE"` // одобрять, если ни одно правило не сработало
+
+	BannedWords struct {
+		Action string   `yaml:"action" env:"ACTION" env-default:"reject"`
+		Words  []string `yaml:"words" env:"WORDS"`
+	} `yaml:"banned_words" env-prefix:"BANNED_WORDS_"`
+
+	ContactsInTitle struct {
+		Action string `yaml:"action" env:"ACTION" env-default:"review"`
+	} `yaml:"contacts_in_title" env-prefix:"CONTACTS_IN_TITLE_"`
+
+	PriceOutlier struct {
+		Action     string  `yaml:"action" env:"ACTION" env-default:"review"`
+		Factor     float64 `yaml:"factor" env:"FACTOR" env-default:"5"`           // во сколько раз цена может отличаться от медианы
+		MinSamples int64   `yaml:"min_samples" env:"MIN_SAMPLES" env-default:"5"` // минимум одобренных объявлений для расчёта медианы
+	} `yaml:"price_outlier" env-prefix:"PRICE_OUTLIER_"`
+
+	NewAccountVelocity struct {
+		Action     string        `yaml:"action" env:"ACTION" env-default:"review"`
+		AccountAge time.Duration `yaml:"account_age" env:"ACCOUNT_AGE" env-default:"72h"` // аккаунт считается новым
+		Window     time.Duration `yaml:"window" env:"WINDOW" env-default:"24h"`
+		MaxItems   int64         `yaml:"max_items" env:"MAX_ITEMS" env-default:"3"` // сколько публикаций за окно допустимо
+	} `yaml:"new_account_velocity" env-prefix:"NEW_ACCOUNT_VELOCITY_"`
+
+	TrustedAccount struct {
+		Action         string        `yaml:"action" env:"ACTION" env-default:"approve"`
+		MinAccountAge  time.Duration `yaml:"min_account_age" env:"MIN_ACCOUNT_AGE" env-default:"720h"`
+		MinApprovedAds int64         `yaml:"min_approved_ads" env:"MIN_APPROVED_ADS" env-default:"3"`
+	} `yaml:"trusted_account" env-prefix:"TRUSTED_ACCOUNT_"`
+}
+
+// Окончательное удаление мягко удалённых записей
+type Retention struct {
+	Disabled bool          `yaml:"disabled" env:"DISABLED"`
+	Period   time.Duration `yaml:"period" env:"PERIOD" env-default:"2160h"`   // сколько хранить удалённые записи (90 дней)
+	Interval time.Duration `yaml:"interval" env:"INTERVAL" env-default:"24h"` // как часто запускать очистку
+}
+
+// Пересчёт аналитики (/admin/stats/series)
+type Analytics struct {
+	Disabled bool          `yaml:"disabled" env:"DISABLED"`
+	Interval time.Duration `yaml:"interval" env:"INTERVAL" env-default:"15m"`
+	Window   time.Duration `yaml:"window" env:"WINDOW" env-default:"840h"` // за сколько последних дней пересчитывать (статусы могут меняться)
+}
+
+// Срок публикации объявлений: активное объявление истекает через TTL после публикации,
+// владелец получает напоминание за ReminderBefore до истечения
+type Ads struct {
+	Disabled       bool          `yaml:"disabled" env:"DISABLED"`                                 // выключить задачу (объявления не истекают)
+	TTL            time.Duration `yaml:"ttl" env:"TTL" env-default:"720h"`                        // срок публикации (30 дней)
+	ReminderBefore time.Duration `yaml:"reminder_before" env:"REMINDER_BEFORE" env-default:"72h"` // за сколько напомнить, 0 - не напоминать
+	Interval       time.Duration `yaml:"interval" env:"INTERVAL" env-default:"1h"`                // как часто проверять сроки
+}
+
+// Трассировка OpenTelemetry
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"EXPORTER" env-default:"none"`                 // otlp, stdout или none
+	Endpoint    string  `yaml:"endpoint" env:"ENDPOINT" env-default:"localhost:4318"`       // адрес OTLP/HTTP коллектора
+	Insecure    bool    `yaml:"insecure" env:"INSECURE"`                                    // OTLP без TLS (локальный коллектор)
+	ServiceName string  `yaml:"service_name" env:"SERVICE_NAME" env-default:"handyman-api"` // service.name в трейсах
+	SampleRatio float64 `yaml:"sample_ratio" env:"SAMPLE_RATIO" env-default:"1"`            // доля записываемых трейсов (0..1)
+}
+
+// Лимит запросов (token bucket): Requests за Period, допускается всплеск до Burst
+type Limit struct {
+	Requests int           `yaml:"requests" env:"REQUESTS"`
+	Period   time.Duration `yaml:"period" env:"PERIOD"`
+	Burst    int           `yaml:"burst" env:"BURST"`
+}
+
+// Ограничение частоты запросов и защита входа от перебора паролей.
+// Группы: global - все запросы с одного IP, auth - вход и регистрация с одного IP,
+// create - создание объявлений, откликов и жалоб одним пользователем
+type RateLimit struct {
+	Disabled   bool `yaml:"disabled" env:"DISABLED"`
+	TrustProxy bool `yaml:"trust_proxy" env:"TRUST_PROXY"` // брать IP из X-Forwarded-For / X-Real-IP (только за своим прокси)
+
+	Global Limit `yaml:"global" env-prefix:"GLOBAL_"`
+	Auth   Limit `yaml:"auth" env-prefix:"AUTH_"`
+	Create Limit `yaml:"create" env-prefix:"CREATE_"`
+
+	Login LoginLockout `yaml:"login" env-prefix:"LOGIN_"`
+}
+
+// Прогрессивная блокировка входа по email: после MaxFailures неудач подряд вход закрыт
+// на Lockout, каждая следующая неудача удваивает срок (не больше MaxLockout)
+type LoginLockout struct {
+	MaxFailures int           `yaml:"max_failures" env:"MAX_FAILURES" env-default:"5"`
+	Lockout     time.Duration `yaml:"lockout" env:"LOCKOUT" env-default:"1m"`
+	MaxLockout  time.Duration `yaml:"max_lockout" env:"MAX_LOCKOUT" env-default:"1h"`
+	ResetAfter  time.Duration `yaml:"reset_after" env:"RESET_AFTER" env-default:"24h"` // счётчик неудач забывается после паузы
+}
+
+// Версии API. Текущая версия обслуживается под /api/v1, старые адреса без префикса
+// (/ads, /auth/login...) - устаревшие псевдонимы v1 с заголовками Deprecation и Sunset
+type API struct {
+	DisableLegacy      bool   `yaml:"disable_legacy" env:"DISABLE_LEGACY"`                                      // отключить старые адреса (404)
+	LegacyDeprecatedAt string `yaml:"legacy_deprecated_at" env:"LEGACY_DEPRECATED_AT" env-default:"2026-10-19"` // дата в заголовке Deprecation (ГГГГ-ММ-ДД)
+	LegacySunset       string `yaml:"legacy_sunset" env:"LEGACY_SUNSET" env-default:"2027-04-19"`               // дата отключения в заголовке Sunset, пусто - не объявлена
+}
+
+const dateLayout = "2006-01-02"
+
+// LegacyDates возвращает даты устаревания и отключения старых адресов (sunset нулевой, если не задан)
+func (a API) LegacyDates() (deprecatedAt, sunset time.Time, err error) {
+	deprecatedAt, err = time.Parse(dateLayout, a.LegacyDeprecatedAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("legacy_deprecated_at: %w", err)
+	}
+	if a.LegacySunset == "" {
+		return deprecatedAt, time.Time{}, nil
+	}
+	sunset, err = time.Parse(dateLayout, a.LegacySunset)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("legacy_sunset: %w", err)
+	}
+	return deprecatedAt, sunset, nil
+}
+
+// Лимиты групп по умолчанию (у общей структуры Limit не может быть своих env-default на каждую группу)
+var defaultLimits = map[string]Limit{
+	"global": {Requests: 300, Period: time.Minute, Burst: 100},
+	"auth":   {Requests: 10, Period: time.Minute, Burst: 5},
+	"create": {Requests: 30, Period: time.Hour, Burst: 5},
+}
+
+// Limits возвращает лимиты по группам
+func (r *RateLimit) Limits() map[string]*Limit {
+	return map[string]*Limit{"global": &r.Global, "auth": &r.Auth, "create": &r.Create}
+}
+
+// applyDefaults заполняет незаданные поля лимитов значениями по умолчанию
+func (r *RateLimit) applyDefaults() {
+	for group, l := range r.Limits() {
+		def := defaultLimits[group]
+		if l.Requests == 0 {
+			l.Requests = def.Requests
+		}
+		if l.Period == 0 {
+			l.Period = def.Period
+		}
+		if l.Burst == 0 {
+			l.Burst = def.Burst
+		}
+	}
 }
 
+// Окружения
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+const defaultConfigPath = "./config/local.yaml"
+
+// MustLoad загружает и проверяет конфиг, при ошибке завершает процесс
 func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+	return cfg
+}
+
+// Load читает конфиг: YAML-файл (если есть), затем переменные окружения поверх него,
+// затем секреты из файлов (*_FILE). Результат проверяется Validate.
+// Файл из CONFIG_PATH обязан существовать, файл по умолчанию - нет (конфиг только из окружения)
+func Load() (*Config, error) {
+	var cfg Config
+
 	configPath := os.Getenv("CONFIG_PATH")
+	explicit := configPath != ""
+	if !explicit {
+		configPath = defaultConfigPath
+	}
+
+	_, statErr := os.Stat(configPath)
+	switch {
+	case statErr == nil:
+		if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config %s: %w", configPath, err)
+		}
+	case explicit || !errors.Is(statErr, fs.ErrNotExist):
+		return nil, fmt.Errorf("config file %s: %w", configPath, statErr)
+	default:
+		log.Printf("config file %s not found, using environment variables only", configPath)
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("cannot read environment: %w", err)
+		}
+	}
+
+	cfg.RateLimit.applyDefaults()
 
-	// Если CONFIG_PATH не установлена, используем значение по умолчанию
-	if configPath == "" {
-		configPath = "./config/local.yaml"
-		log.Printf("CONFIG_PATH not set, using default: %s", configPath)
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, err
 	}
 
-	//проверка существования файла
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file %s does not exist", configPath)
+	// вне prod пустой секрет заменяется случайным: токены не переживут перезапуск, но и не подделываются
+	if cfg.JWT.SecretKey == "" && cfg.Env != EnvProd {
+		cfg.JWT.SecretKey = rand.Text()
+		log.Printf("JWT_SECRET_KEY not set, using a random secret for this run")
 	}
 
-	var cfg Config
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config: %s", err)
+	return &cfg, nil
+}
+
+// readSecretFiles подставляет секреты из файлов, указанных в DB_PASSWORD_FILE и JWT_SECRET_KEY_FILE
+// (Docker/Kubernetes secrets). Файл имеет приоритет над значением из YAML и окружения
+func (c *Config) readSecretFiles() error {
+	secrets := []struct {
+		env string
+		dst *string
+	}{
+		{"DB_PASSWORD_FILE", &c.DB.Password},
+		{"JWT_SECRET_KEY_FILE", &c.JWT.SecretKey},
 	}
 
-	return &cfg
+	for _, s := range secrets {
+		path := os.Getenv(s.env)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.env, err)
+		}
+		*s.dst = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
 }
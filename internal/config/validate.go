@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// известные слабые секреты, которые нельзя использовать в prod
+var weakSecrets = map[string]bool{
+	"key": true, "secret": true, "changeme": true, "password": true, "jwt_secret": true,
+}
+
+const minProdSecretLen = 32
+
+// Validate проверяет конфиг целиком и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvLocal, EnvDev, EnvProd:
+	default:
+		add("env: unknown value %q (want %s, %s or %s)", c.Env, EnvLocal, EnvDev, EnvProd)
+	}
+
+	// HTTP
+	if c.HTTPServer.Address == "" {
+		add("http_server.address: must not be empty")
+	}
+	if c.HTTPServer.TimeOut <= 0 {
+		add("http_server.timeout: must be positive")
+	}
+	if c.HTTPServer.IdleTimeOut <= 0 {
+		add("http_server.idle_timeout: must be positive")
+	}
+	if c.HTTPServer.ShutdownTimeout <= 0 {
+		add("http_server.shutdown_timeout: must be positive")
+	}
+	if (c.HTTPServer.TLS.CertFile == "") != (c.HTTPServer.TLS.KeyFile == "") {
+		add("http_server.tls: cert_file and key_file must be set together")
+	}
+
+	// БД
+	if c.DB.Host == "" {
+		add("db.host: must not be empty")
+	}
+	if c.DB.Port <= 0 || c.DB.Port > 65535 {
+		add("db.port: %d is out of range", c.DB.Port)
+	}
+	if c.DB.MaxOpenConns <= 0 {
+		add("db.max_open_conns: must be positive")
+	}
+	if c.DB.MaxIdleConns < 0 {
+		add("db.max_idle_conns: must not be negative")
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		add("db.max_idle_conns: %d exceeds max_open_conns %d", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+	}
+	if c.DB.ConnMaxLifetime < 0 {
+		add("db.conn_max_lifetime: must not be negative")
+	}
+	if c.DB.PingTimeout <= 0 {
+		add("db.ping_timeout: must be positive")
+	}
+
+	// JWT
+	secret := c.JWT.SecretKey
+	switch {
+	case secret == "":
+		add("jwt.secret_key: must be set")
+	case c.Env == EnvProd && weakSecrets[strings.ToLower(secret)]:
+		add("jwt.secret_key: default or well-known secret is not allowed in prod")
+	case c.Env == EnvProd && len(secret) < minProdSecretLen:
+		add("jwt.secret_key: must be at least %d characters in prod", minProdSecretLen)
+	}
+
+	// Автомодерация
+	m := c.Moderation
+	for _, rule := range []struct{ name, action string }{
+		{"banned_words", m.BannedWords.Action},
+		{"contacts_in_title", m.ContactsInTitle.Action},
+		{"price_outlier", m.PriceOutlier.Action},
+		{"new_account_velocity", m.NewAccountVelocity.Action},
+		{"trusted_account", m.TrustedAccount.Action},
+	} {
+		switch rule.action {
+		case "approve", "reject", "review":
+		default:
+			add("moderation.%s.action: unknown value %q", rule.name, rule.action)
+		}
+	}
+
+	// Фоновые задачи
+	if !c.Retention.Disabled && (c.Retention.Interval <= 0 || c.Retention.Period <= 0) {
+		add("retention: interval and period must be positive")
+	}
+	if !c.Analytics.Disabled && (c.Analytics.Interval <= 0 || c.Analytics.Window <= 0) {
+		add("analytics: interval and window must be positive")
+	}
+	if c.Ads.TTL <= 0 {
+		add("ads.ttl: must be positive")
+	}
+	if c.Ads.ReminderBefore < 0 || c.Ads.ReminderBefore >= c.Ads.TTL {
+		add("ads.reminder_before: must be in [0, ttl)")
+	}
+	if !c.Ads.Disabled && c.Ads.Interval <= 0 {
+		add("ads.interval: must be positive")
+	}
+
+	// Ограничение частоты запросов
+	if !c.RateLimit.Disabled {
+		for _, group := range []string{"global", "auth", "create"} {
+			l := c.RateLimit.Limits()[group]
+			if l.Requests <= 0 || l.Period <= 0 || l.Burst <= 0 {
+				add("rate_limit.%s: requests, period and burst must be positive", group)
+			}
+		}
+		login := c.RateLimit.Login
+		if login.MaxFailures <= 0 || login.Lockout <= 0 || login.ResetAfter <= 0 {
+			add("rate_limit.login: max_failures, lockout and reset_after must be positive")
+		}
+		if login.MaxLockout < login.Lockout {
+			add("rate_limit.login.max_lockout: must not be less than lockout")
+		}
+	}
+
+	// Версии API
+	if !c.API.DisableLegacy {
+		deprecatedAt, sunset, err := c.API.LegacyDates()
+		switch {
+		case err != nil:
+			add("api.%w", err)
+		case !sunset.IsZero() && sunset.Before(deprecatedAt):
+			add("api.legacy_sunset: must not be before legacy_deprecated_at")
+		}
+	}
+
+	// Трассировка
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		add("tracing.exporter: unknown value %q (want otlp, stdout or none)", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio: %v is out of range [0, 1]", c.Tracing.SampleRatio)
+	}
+
+	return errors.Join(errs...)
+}
+
+const redacted = "[REDACTED]"
+
+// Redacted возвращает копию конфига со скрытыми секретами (для вывода и логов)
+func (c Config) Redacted() Config {
+	if c.DB.Password != "" {
+		c.DB.Password = redacted
+	}
+	if c.JWT.SecretKey != "" {
+		c.JWT.SecretKey = redacted
+	}
+	return c
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Writer построчно пишет таблицу в ответ, не держа её целиком в памяти
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// New выставляет заголовки ответа и возвращает writer для format (csv, xlsx)
+func New(w http.ResponseWriter, format, filename string) (Writer, error) {
+	switch format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		// BOM, чтобы Excel корректно открыл кириллицу
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: csv.NewWriter(w), flusher: flusherOf(w)}, nil
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func flusherOf(w http.ResponseWriter) http.Flusher {
+	f, _ := w.(http.Flusher)
+	return f
+}
+
+// ======================================================================
+// CSV
+// ======================================================================
+
+type csvWriter struct {
+	w       *csv.Writer
+	flusher http.Flusher
+	rows    int
+}
+
+func (c *csvWriter) Write(row []string) error {
+	if err := c.w.Write(cells(row)); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%500 == 0 {
+		c.w.Flush()
+		if c.flusher != nil {
+			c.flusher.Flush()
+		}
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ======================================================================
+// XLSX (один лист, строки как inline strings, пишется потоком в zip)
+// ======================================================================
+
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	rows  int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+
+	static := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`,
+		"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		f, err := z.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, static[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zip: z, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	x.rows++
+	if _, err := fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows); err != nil {
+		return err
+	}
+	for i, value := range cells(row) {
+		if _, err := fmt.Fprintf(x.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, column(i), x.rows); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(x.sheet, []byte(value)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(x.sheet, `</t></is></c>`); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(x.sheet, `</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// column - буквенное имя колонки: 0 → A, 25 → Z, 26 → AA
+func column(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// ======================================================================
+// Форматирование значений
+// ======================================================================
+
+// cells экранирует значения, которые табличный редактор принял бы за формулу (=, +, -, @, табуляция или
+// возврат каретки в начале) - префикс ': в выгрузку попадают названия, описания и email пользователей
+func cells(row []string) []string {
+	out := make([]string, len(row))
+	for i, value := range row {
+		if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		out[i] = value
+	}
+	return out
+}
+
+func Uint(v uint) string { return strconv.FormatUint(uint64(v), 10) }
+
+func Int(v int64) string { return strconv.FormatInt(v, 10) }
+
+func Float(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+func FloatPtr(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return Float(*v)
+}
+
+func Bool(v bool) string { return strconv.FormatBool(v) }
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"encoding/json"
+	"go-api/internal/models"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// АНАЛИТИКА
+// ======================================================================
+
+var seriesIntervals = map[string]bool{"day": true, "week": true, "month": true}
+
+// GetStatsSeriesHandler - временные ряды по предагрегированным данным
+// (?interval=day|week|month&from=2026-01-01&to=2026-03-01&group_by=category|location&category_id=&location=)
+func GetStatsSeriesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+
+		interval := q.Get("interval")
+		if interval == "" {
+			interval = "day"
+		}
+		if !seriesIntervals[interval] {
+			http.Error(w, `{"error": "interval must be day, week or month"}`, http.StatusBadRequest)
+			return
+		}
+
+		to := time.Now()
+		from := to.AddDate(0, 0, -30)
+		if v := q.Get("from"); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				http.Error(w, `{"error": "invalid from, expected YYYY-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			from = t
+		}
+		if v := q.Get("to"); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				http.Error(w, `{"error": "invalid to, expected YYYY-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			to = t
+		}
+
+		// Разрез: total (по умолчанию), category или location.
+		// Фильтр category_id / location выбирает одно значение разреза
+		dimension := "total"
+		dimValue := ""
+		switch {
+		case q.Get("category_id") != "":
+			dimension, dimValue = "category", q.Get("category_id")
+		case q.Get("location") != "":
+			dimension, dimValue = "location", q.Get("location")
+		case q.Get("group_by") == "category" || q.Get("group_by") == "location":
+			dimension = q.Get("group_by")
+		case q.Get("group_by") != "":
+			http.Error(w, `{"error": "group_by must be category or location"}`, http.StatusBadRequest)
+			return
+		}
+
+		type Point struct {
+			Period                     time.Time `json:"period"`
+			Registrations              int64     `json:"registrations"`
+			AdsCreated                 int64     `json:"ads_created"`
+			AdsApproved                int64     `json:"ads_approved"`
+			Responses                  int64     `json:"responses"`
+			ResponsesAccepted          int64     `json:"responses_accepted"`
+			AcceptanceRate             float64   `json:"acceptance_rate"`
+			MedianFirstResponseMinutes *float64  `json:"median_first_response_minutes"`
+		}
+
+		type Row struct {
+			Point
+			DimValue string
+		}
+
+		counts := db.Model(&models.StatsRollup{}).
+			Select("date_trunc(?, day) as period, dim_value, "+
+				"SUM(registrations) as registrations, SUM(ads_created) as ads_created, SUM(ads_approved) as ads_approved, "+
+				"SUM(responses) as responses, SUM(responses_accepted) as responses_accepted", interval).
+			Where("dimension = ? AND day >= ? AND day <= ?", dimension, from, to).
+			Group("period, dim_value").
+			Order("period")
+		if dimValue != "" {
+			if dimension == "location" {
+				dimValue = normalizeLocation(dimValue)
+			}
+			counts = counts.Where("dim_value = ?", dimValue)
+		}
+
+		var rows []Row
+		if err := counts.Scan(&rows).Error; err != nil {
+			logger.Error("failed to get stats series", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		// Медиана времени до первого отклика считается по объявлениям периода
+		dimExpr := "''"
+		groupBy := "period"
+		switch dimension {
+		case "category":
+			dimExpr = "category_id::text"
+			groupBy += ", " + dimExpr
+		case "location":
+			dimExpr = "location"
+			groupBy += ", " + dimExpr
+		}
+
+		type MedianRow struct {
+			Period   time.Time
+			DimValue string
+			Median   float64
+		}
+
+		medians := db.Model(&models.AdFirstResponse{}).
+			Select("date_trunc(?, day) as period, "+dimExpr+" as dim_value, "+
+				"percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds) as median", interval).
+			Where("day >= ? AND day <= ?", from, to).
+			Group(groupBy)
+		if dimValue != "" {
+			medians = medians.Where(dimExpr+" = ?", dimValue)
+		}
+
+		var medianRows []MedianRow
+		if err := medians.Scan(&medianRows).Error; err != nil {
+			logger.Error("failed to get first response medians", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		type key struct {
+			period   int64
+			dimValue string
+		}
+		medianByKey := make(map[key]float64, len(medianRows))
+		for _, m := range medianRows {
+			medianByKey[key{m.Period.Unix(), m.DimValue}] = m.Median / 60
+		}
+
+		series := map[string][]Point{}
+		for _, row := range rows {
+			p := row.Point
+			if p.Responses > 0 {
+				p.AcceptanceRate = float64(p.ResponsesAccepted) / float64(p.Responses)
+			}
+			if m, ok := medianByKey[key{p.Period.Unix(), row.DimValue}]; ok {
+				p.MedianFirstResponseMinutes = &m
+			}
+			series[row.DimValue] = append(series[row.DimValue], p)
+		}
+
+		if dimension == "total" {
+			points := series[""]
+			if points == nil {
+				points = []Point{}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"interval": interval,
+				"from":     from.Format(time.DateOnly),
+				"to":       to.Format(time.DateOnly),
+				"series":   points,
+			})
+			return
+		}
+
+		// Названия категорий для разреза по категориям
+		names := map[string]string{}
+		if dimension == "category" {
+			var categories []models.Category
+			db.Unscoped().Select("id, name").Find(&categories)
+			for _, c := range categories {
+				names[strconv.FormatUint(uint64(c.ID), 10)] = c.Name
+			}
+		}
+
+		type Group struct {
+			Key    string  `json:"key"`
+			Name   string  `json:"name,omitempty"`
+			Series []Point `json:"series"`
+		}
+
+		groups := make([]Group, 0, len(series))
+		for k, points := range series {
+			groups = append(groups, Group{Key: k, Name: names[k], Series: points})
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"interval":  interval,
+			"from":      from.Format(time.DateOnly),
+			"to":        to.Format(time.DateOnly),
+			"dimension": dimension,
+			"groups":    groups,
+		})
+	}
+}
+
+// локации в агрегатах хранятся в нижнем регистре без пробелов по краям
+func normalizeLocation(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
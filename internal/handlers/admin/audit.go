@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"encoding/json"
+	"go-api/internal/audit"
+	"go-api/internal/models"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// ЖУРНАЛ АУДИТА
+// ======================================================================
+
+// recordAudit пишет действие администратора в журнал; ошибка записи не прерывает запрос
+func recordAudit(db *gorm.DB, logger *slog.Logger, r *http.Request, action, targetType string, targetID interface{}, before, after interface{}) {
+	if err := audit.Record(db, r, action, targetType, targetID, before, after); err != nil {
+		logger.Error("failed to write audit log", "error", err, "action", action, "target_id", targetID)
+	}
+}
+
+// GetAuditLogHandler - журнал действий администраторов
+// (?actor_id=&action=&target_type=&target_id=&request_id=&from=&to=)
+func GetAuditLogHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		query := db.Model(&models.AuditLog{})
+
+		// Фильтры
+		if actorID := r.URL.Query().Get("actor_id"); actorID != "" {
+			query = query.Where("actor_id = ?", actorID)
+		}
+		if action := r.URL.Query().Get("action"); action != "" {
+			query = query.Where("action = ?", action)
+		}
+		if targetType := r.URL.Query().Get("target_type"); targetType != "" {
+			query = query.Where("target_type = ?", targetType)
+		}
+		if targetID := r.URL.Query().Get("target_id"); targetID != "" {
+			query = query.Where("target_id = ?", targetID)
+		}
+		if requestID := r.URL.Query().Get("request_id"); requestID != "" {
+			query = query.Where("request_id = ?", requestID)
+		}
+		if from := r.URL.Query().Get("from"); from != "" {
+			t, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				http.Error(w, `{"error": "invalid from, expected RFC3339"}`, http.StatusBadRequest)
+				return
+			}
+			query = query.Where("created_at >= ?", t)
+		}
+		if to := r.URL.Query().Get("to"); to != "" {
+			t, err := time.Parse(time.RFC3339, to)
+			if err != nil {
+				http.Error(w, `{"error": "invalid to, expected RFC3339"}`, http.StatusBadRequest)
+				return
+			}
+			query = query.Where("created_at < ?", t)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var entries []models.AuditLog
+		if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+			logger.Error("failed to get audit log", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		type AuditEntry struct {
+			ID         uint            `json:"id"`
+			CreatedAt  time.Time       `json:"created_at"`
+			ActorID    uint            `json:"actor_id"`
+			ActorEmail string          `json:"actor_email"`
+			Action     string          `json:"action"`
+			TargetType string          `json:"target_type"`
+			TargetID   string          `json:"target_id"`
+			Before     json.RawMessage `json:"before"`
+			After      json.RawMessage `json:"after"`
+			RequestID  string          `json:"request_id"`
+			IP         string          `json:"ip"`
+		}
+
+		// email администраторов (включая удалённых)
+		actorIDs := make([]uint, 0, len(entries))
+		for _, e := range entries {
+			actorIDs = append(actorIDs, e.ActorID)
+		}
+		var actors []models.User
+		db.Unscoped().Select("id, email").Where("id IN ?", actorIDs).Find(&actors)
+		emails := make(map[uint]string, len(actors))
+		for _, a := range actors {
+			emails[a.ID] = a.Email
+		}
+
+		result := make([]AuditEntry, len(entries))
+		for i, e := range entries {
+			result[i] = AuditEntry{
+				ID:         e.ID,
+				CreatedAt:  e.CreatedAt,
+				ActorID:    e.ActorID,
+				ActorEmail: emails[e.ActorID],
+				Action:     e.Action,
+				TargetType: e.TargetType,
+				TargetID:   e.TargetID,
+				Before:     rawJSON(e.Before),
+				After:      rawJSON(e.After),
+				RequestID:  e.RequestID,
+				IP:         e.IP,
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries": result,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		})
+	}
+}
+
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// УДАЛЁННЫЕ ЗАПИСИ — ПРОСМОТР И ВОССТАНОВЛЕНИЕ
+// ======================================================================
+
+// Сущности с мягким удалением: таблица и поля для списка
+var deletedEntities = map[string]struct {
+	table   string
+	columns string
+}{
+	"ads":         {"ads", "id, title, user_id, category_id, price_unit_id, status, created_at, deleted_at"},
+	"users":       {"users", "id, email, name, role_id, created_at, deleted_at"},
+	"responses":   {"responses", "id, ad_id, worker_id, status, created_at, deleted_at"},
+	"categories":  {"categories", "id, name, created_at, deleted_at"},
+	"price-units": {"price_units", "id, name, created_at, deleted_at"},
+}
+
+// errRestoreConflict - восстановление невозможно, пока удалён связанный объект
+type errRestoreConflict struct{ reason string }
+
+func (e errRestoreConflict) Error() string { return e.reason }
+
+// GetDeletedHandler - список мягко удалённых записей (/admin/deleted/{entity})
+func GetDeletedHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		entity := chi.URLParam(r, "entity")
+		def, ok := deletedEntities[entity]
+		if !ok {
+			http.Error(w, `{"error": "unknown entity, expected ads, users, responses, categories or price-units"}`, http.StatusBadRequest)
+			return
+		}
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		var total int64
+		db.Table(def.table).Where("deleted_at IS NOT NULL").Count(&total)
+
+		var rows []map[string]interface{}
+		if err := db.Table(def.table).
+			Select(def.columns).
+			Where("deleted_at IS NOT NULL").
+			Order("deleted_at DESC").
+			Limit(limit).
+			Offset(offset).
+			Find(&rows).Error; err != nil {
+			logger.Error("failed to get deleted rows", "error", err, "entity", entity)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			entity:   rows,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
+
+// RestoreDeletedHandler - восстановить мягко удалённую запись.
+// Пользователь восстанавливается вместе с профилем мастера; объявление и отклик -
+// только если живы владелец, справочники и объявление
+func RestoreDeletedHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		entity := chi.URLParam(r, "entity")
+		if _, ok := deletedEntities[entity]; !ok {
+			http.Error(w, `{"error": "unknown entity, expected ads, users, responses, categories or price-units"}`, http.StatusBadRequest)
+			return
+		}
+
+		idStr := chi.URLParam(r, "id")
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid id"}`, http.StatusBadRequest)
+			return
+		}
+
+		var restored []string
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			restored, err = restore(tx, entity, uint(id))
+			return err
+		})
+		if err != nil {
+			var conflict errRestoreConflict
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				http.Error(w, `{"error": "deleted record not found"}`, http.StatusNotFound)
+			case errors.As(err, &conflict):
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{"error": conflict.reason})
+			default:
+				logger.Error("failed to restore record", "error", err, "entity", entity, "id", id)
+				http.Error(w, `{"error": "failed to restore record"}`, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		invalidateReferences(restored...)
+		recordAudit(db, logger, r, entity+".restore", entity, id,
+			map[string]bool{"deleted": true}, map[string]interface{}{"deleted": false, "restored": restored})
+
+		logger.Info("record restored by admin", "entity", entity, "id", id, "restored", restored)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "record restored successfully",
+			"entity":   entity,
+			"id":       id,
+			"restored": restored,
+		})
+	}
+}
+
+// restore снимает deleted_at и возвращает список восстановленных объектов
+func restore(tx *gorm.DB, entity string, id uint) ([]string, error) {
+	switch entity {
+	case "ads":
+		var ad models.Ad
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&ad).Error; err != nil {
+			return nil, err
+		}
+		if !alive(tx, &models.User{}, ad.UserID) {
+			return nil, errRestoreConflict{"ad owner is deleted, restore the user first"}
+		}
+		if !alive(tx, &models.Category{}, ad.CategoryID) || !alive(tx, &models.PriceUnit{}, ad.PriceUnitID) {
+			return nil, errRestoreConflict{"ad category or price unit is deleted, restore it first"}
+		}
+		return []string{"ad"}, undelete(tx, &models.Ad{}, "id = ?", id)
+
+	case "users":
+		var user models.User
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error; err != nil {
+			return nil, err
+		}
+		if err := undelete(tx, &models.User{}, "id = ?", id); err != nil {
+			return nil, err
+		}
+		// Профиль мастера, удалённый вместе с пользователем или позже
+		result := tx.Unscoped().Model(&models.WorkerProfile{}).
+			Where("user_id = ? AND deleted_at >= ?", id, user.DeletedAt.Time).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		restored := []string{"user"}
+		if result.RowsAffected > 0 {
+			restored = append(restored, "worker_profile")
+		}
+		return restored, nil
+
+	case "responses":
+		var response models.Response
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&response).Error; err != nil {
+			return nil, err
+		}
+		if !alive(tx, &models.Ad{}, response.AdID) {
+			return nil, errRestoreConflict{"response ad is deleted, restore the ad first"}
+		}
+		var duplicates int64
+		if err := tx.Model(&models.Response{}).
+			Where("ad_id = ? AND worker_id = ?", response.AdID, response.WorkerID).
+			Count(&duplicates).Error; err != nil {
+			return nil, err
+		}
+		if duplicates > 0 {
+			return nil, errRestoreConflict{"worker already has another response to this ad"}
+		}
+		return []string{"response"}, undelete(tx, &models.Response{}, "id = ?", id)
+
+	case "categories":
+		if to := storage.ResolveRedirect(tx, storage.RedirectCategory, id); to != id {
+			return nil, errRestoreConflict{fmt.Sprintf("category was merged into %d", to)}
+		}
+		return []string{"category"}, undelete(tx, &models.Category{}, "id = ? AND deleted_at IS NOT NULL", id)
+
+	case "price-units":
+		if to := storage.ResolveRedirect(tx, storage.RedirectPriceUnit, id); to != id {
+			return nil, errRestoreConflict{fmt.Sprintf("price unit was merged into %d", to)}
+		}
+		return []string{"price_unit"}, undelete(tx, &models.PriceUnit{}, "id = ? AND deleted_at IS NOT NULL", id)
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func undelete(tx *gorm.DB, model interface{}, query string, args ...interface{}) error {
+	result := tx.Unscoped().Model(model).Where(query, args...).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func alive(tx *gorm.DB, model interface{}, id uint) bool {
+	var count int64
+	tx.Model(model).Where("id = ?", id).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"go-api/internal/export"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// ЭКСПОРТ СПИСКОВ
+// ======================================================================
+
+// Экспорт использует те же запросы и фильтры, что и списки, но без limit/offset.
+// Строки читаются курсором и сразу пишутся в ответ (?format=csv|xlsx), поэтому срок записи ответа
+// продлевается до timeout (http_server.export_timeout) вместо общего таймаута сервера
+
+// ExportUsersHandler - выгрузка пользователей
+func ExportUsersHandler(db *gorm.DB, logger *slog.Logger, timeout time.Duration) http.HandlerFunc {
+	header := []string{"id", "email", "name", "phone", "role", "created_at", "have_worker_profile", "ads_count", "responses_count"}
+
+	return exportHandler(db, logger, timeout, "users", header, usersQuery, func(scan func(dest interface{}) error) ([]string, error) {
+		var u userInfo
+		if err := scan(&u); err != nil {
+			return nil, err
+		}
+		return []string{
+			export.Uint(u.ID), u.Email, u.Name, u.Phone, u.RoleName, u.CreatedAt.Format(time.RFC3339),
+			export.Bool(u.HaveWorkerProfile), export.Int(u.AdsCount), export.Int(u.ResponsesCount),
+		}, nil
+	})
+}
+
+// ExportAdsHandler - выгрузка объявлений
+func ExportAdsHandler(db *gorm.DB, logger *slog.Logger, timeout time.Duration) http.HandlerFunc {
+	header := []string{"id", "title", "price", "price_unit", "category", "location", "status", "created_at", "user_id", "user_name", "user_email", "responses_count"}
+
+	return exportHandler(db, logger, timeout, "ads", header, adsQuery, func(scan func(dest interface{}) error) ([]string, error) {
+		var a adInfo
+		if err := scan(&a); err != nil {
+			return nil, err
+		}
+		return []string{
+			export.Uint(a.ID), a.Title, export.Float(a.Price), a.PriceUnitName, a.CategoryName, a.Location, a.Status,
+			a.CreatedAt.Format(time.RFC3339), export.Uint(a.UserID), a.UserName, a.UserEmail, export.Int(a.ResponsesCount),
+		}, nil
+	})
+}
+
+// ExportResponsesHandler - выгрузка откликов
+func ExportResponsesHandler(db *gorm.DB, logger *slog.Logger, timeout time.Duration) http.HandlerFunc {
+	header := []string{"id", "ad_id", "ad_title", "worker_id", "worker_name", "worker_email", "message", "proposed_price", "status", "moderation_status", "created_at"}
+
+	return exportHandler(db, logger, timeout, "responses", header, responsesQuery, func(scan func(dest interface{}) error) ([]string, error) {
+		var resp responseInfo
+		if err := scan(&resp); err != nil {
+			return nil, err
+		}
+		return []string{
+			export.Uint(resp.ID), export.Uint(resp.AdID), resp.AdTitle, export.Uint(resp.WorkerID), resp.WorkerName, resp.WorkerEmail,
+			resp.Message, export.FloatPtr(resp.ProposedPrice), resp.Status, resp.ModerationStatus, resp.CreatedAt.Format(time.RFC3339),
+		}, nil
+	})
+}
+
+func exportHandler(db *gorm.DB, logger *slog.Logger, timeout time.Duration, name string, header []string,
+	query func(db *gorm.DB, r *http.Request) *gorm.DB,
+	row func(scan func(dest interface{}) error) ([]string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "csv" && format != "xlsx" {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error": "format must be csv or xlsx"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			logger.Warn("failed to extend export write deadline", "entity", name, "error", err)
+		}
+
+		rows, err := query(db.WithContext(r.Context()), r).Rows()
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			logger.Error("failed to export", "entity", name, "error", err)
+			return
+		}
+		defer rows.Close()
+
+		filename := name + "-" + time.Now().Format("20060102-150405")
+		out, err := export.New(w, format, filename)
+		if err != nil {
+			logger.Error("failed to start export", "entity", name, "error", err)
+			return
+		}
+
+		// После начала записи статус уже отправлен, поэтому ошибки только логируются
+		if err := out.Write(header); err != nil {
+			logger.Error("failed to write export", "entity", name, "error", err)
+			return
+		}
+
+		scan := func(dest interface{}) error { return db.ScanRows(rows, dest) }
+		count := 0
+		for rows.Next() {
+			values, err := row(scan)
+			if err != nil {
+				logger.Error("failed to scan export row", "entity", name, "error", err)
+				return
+			}
+			if err := out.Write(values); err != nil {
+				logger.Error("failed to write export", "entity", name, "error", err)
+				return
+			}
+			count++
+		}
+		if err := rows.Err(); err != nil {
+			logger.Error("failed to read export rows", "entity", name, "error", err)
+			return
+		}
+		if err := out.Close(); err != nil {
+			logger.Error("failed to finish export", "entity", name, "error", err)
+			return
+		}
+
+		logger.Info("Экспорт выполнен", "entity", name, "format", format, "rows", count)
+	}
+}
@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// ИМПОРТ СПРАВОЧНИКОВ
+// ======================================================================
+
+const maxImportSize = 5 << 20
+
+// importRowError - ошибка в строке файла (row - номер строки в файле, с заголовком)
+type importRowError struct {
+	Row   int    `json:"row"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ImportCategoriesHandler - массовое создание категорий из CSV (колонка name, ?dry_run=true)
+func ImportCategoriesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return importHandler(db, logger, "category", &models.Category{}, func(tx *gorm.DB, name string) (interface{}, error) {
+		slug, err := storage.UniqueCategorySlug(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Category{Name: name, Slug: slug}, nil
+	})
+}
+
+// ImportPriceUnitsHandler - массовое создание единиц цены из CSV (колонка name, ?dry_run=true)
+func ImportPriceUnitsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return importHandler(db, logger, "price_unit", &models.PriceUnit{}, func(tx *gorm.DB, name string) (interface{}, error) {
+		return &models.PriceUnit{Name: name}, nil
+	})
+}
+
+// importHandler принимает CSV файлом (multipart, поле file) или телом запроса.
+// Импорт атомарный: при любой ошибке в строках ничего не создаётся и возвращается отчёт
+func importHandler(db *gorm.DB, logger *slog.Logger, entity string, model interface{}, newRecord func(tx *gorm.DB, name string) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+
+		body, err := importBody(w, r)
+		if err != nil {
+			http.Error(w, `{"error": "file is required"}`, http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+
+		names, rowErrors, err := parseImportNames(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid csv: " + err.Error()})
+			return
+		}
+
+		// Уже существующие (в том числе удалённые) имена, без учёта регистра
+		lower := make([]string, 0, len(names))
+		for _, n := range names {
+			lower = append(lower, strings.ToLower(n.name))
+		}
+		type existingRow struct {
+			Name    string
+			Deleted bool
+		}
+		var existing []existingRow
+		if len(lower) > 0 {
+			if err := db.Unscoped().Model(model).
+				Select("name, deleted_at IS NOT NULL as deleted").
+				Where("LOWER(name) IN ?", lower).
+				Scan(&existing).Error; err != nil {
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+				logger.Error("failed to check existing names", "entity", entity, "error", err)
+				return
+			}
+		}
+		existingByName := make(map[string]bool, len(existing))
+		for _, e := range existing {
+			existingByName[strings.ToLower(e.Name)] = e.Deleted
+		}
+
+		var toCreate []string
+		for _, n := range names {
+			deleted, ok := existingByName[strings.ToLower(n.name)]
+			switch {
+			case ok && deleted:
+				rowErrors = append(rowErrors, importRowError{Row: n.row, Name: n.name, Error: "exists as deleted, restore it instead"})
+			case ok:
+				rowErrors = append(rowErrors, importRowError{Row: n.row, Name: n.name, Error: "already exists"})
+			default:
+				toCreate = append(toCreate, n.name)
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "import has invalid rows, nothing was created",
+				"dry_run": dryRun,
+				"valid":   len(toCreate),
+				"errors":  rowErrors,
+			})
+			return
+		}
+
+		if dryRun {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "dry run, nothing was created",
+				"dry_run": true,
+				"valid":   len(toCreate),
+				"names":   toCreate,
+			})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, name := range toCreate {
+				record, err := newRecord(tx, name)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(record).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			http.Error(w, `{"error": "failed to import"}`, http.StatusInternalServerError)
+			logger.Error("failed to import", "entity", entity, "error", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Import completed successfully",
+			"created": len(toCreate),
+			"names":   toCreate,
+		})
+
+		invalidateReferences(entity)
+		recordAudit(db, logger, r, entity+".import", entity, "", nil, map[string]interface{}{"names": toCreate})
+
+		logger.Info("Импорт справочника выполнен", "entity", entity, "created", len(toCreate))
+	}
+}
+
+func importBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	if r.ContentLength == 0 {
+		return nil, errors.New("empty body")
+	}
+	return r.Body, nil
+}
+
+type importName struct {
+	row  int
+	name string
+}
+
+// parseImportNames читает колонку name. Пустые значения и повторы внутри файла попадают в отчёт
+func parseImportNames(body io.Reader) ([]importName, []importRowError, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	column := -1
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\xEF\xBB\xBF")), "name") {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return nil, nil, errors.New("column name not found")
+	}
+
+	var names []importName
+	var rowErrors []importRowError
+	seen := map[string]int{}
+	row := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row++
+
+		name := ""
+		if column < len(record) {
+			name = strings.TrimSpace(record[column])
+		}
+		if name == "" {
+			rowErrors = append(rowErrors, importRowError{Row: row, Error: "name is required"})
+			continue
+		}
+		if utf8.RuneCountInString(name) > 255 {
+			rowErrors = append(rowErrors, importRowError{Row: row, Name: name, Error: "name is too long"})
+			continue
+		}
+		if first, ok := seen[strings.ToLower(name)]; ok {
+			rowErrors = append(rowErrors, importRowError{Row: row, Name: name, Error: "duplicate of row " + strconv.Itoa(first)})
+			continue
+		}
+		seen[strings.ToLower(name)] = row
+		names = append(names, importName{row: row, name: name})
+	}
+
+	return names, rowErrors, nil
+}
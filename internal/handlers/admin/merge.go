@@ -0,0 +1,292 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// СЛИЯНИЕ СПРАВОЧНИКОВ
+// ======================================================================
+
+// Справочники, которые можно сливать: сущность для истории и перенаправлений и таблица
+var mergeEntities = map[string]struct {
+	table string
+	model interface{}
+}{
+	storage.RedirectCategory:  {"categories", &models.Category{}},
+	storage.RedirectPriceUnit: {"price_units", &models.PriceUnit{}},
+}
+
+// errMergeInvalid - слияние невозможно из-за структуры данных (400)
+type errMergeInvalid struct{ reason string }
+
+func (e errMergeInvalid) Error() string { return e.reason }
+
+// MergeCategoriesHandler - слить категории-дубликаты в целевую
+func MergeCategoriesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return mergeHandler(db, logger, storage.RedirectCategory)
+}
+
+// MergePriceUnitsHandler - слить единицы цены-дубликаты в целевую
+func MergePriceUnitsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return mergeHandler(db, logger, storage.RedirectPriceUnit)
+}
+
+// mergeHandler переносит все ссылки с source_ids на target_id в одной транзакции,
+// удаляет источники и оставляет перенаправления со старых ID
+func mergeHandler(db *gorm.DB, logger *slog.Logger, entity string) http.HandlerFunc {
+	def := mergeEntities[entity]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			SourceIDs []uint `json:"source_ids"`
+			TargetID  uint   `json:"target_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		sources := make([]uint, 0, len(req.SourceIDs))
+		seen := map[uint]bool{}
+		for _, id := range req.SourceIDs {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				sources = append(sources, id)
+			}
+		}
+		if req.TargetID == 0 || len(sources) == 0 {
+			http.Error(w, `{"error": "source_ids and target_id are required"}`, http.StatusBadRequest)
+			return
+		}
+		if seen[req.TargetID] {
+			http.Error(w, `{"error": "target_id cannot be among source_ids"}`, http.StatusBadRequest)
+			return
+		}
+
+		type named struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		}
+
+		var target named
+		if result := db.Table(def.table).Select("id, name").Where("id = ? AND deleted_at IS NULL", req.TargetID).Limit(1).Find(&target); result.Error != nil || result.RowsAffected == 0 {
+			http.Error(w, `{"error": "target not found"}`, http.StatusNotFound)
+			return
+		}
+
+		var found []named
+		if err := db.Table(def.table).Select("id, name").Where("id IN ? AND deleted_at IS NULL", sources).Order("id").Find(&found).Error; err != nil {
+			logger.Error("failed to load merge sources", "entity", entity, "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		if len(found) != len(sources) {
+			http.Error(w, `{"error": "some source_ids not found"}`, http.StatusNotFound)
+			return
+		}
+
+		adminID, _ := r.Context().Value("user_id").(uint)
+
+		var merge models.ReferenceMerge
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			merge, err = mergeReferences(tx, entity, sources, req.TargetID)
+			if err != nil {
+				return err
+			}
+
+			ids, _ := json.Marshal(sources)
+			names, _ := json.Marshal(found)
+			merge.SourceIDs = string(ids)
+			merge.SourceNames = string(names)
+			merge.MergedBy = adminID
+			if err := tx.Create(&merge).Error; err != nil {
+				return err
+			}
+
+			// Перенаправления, которые вели в источники, теперь ведут в цель (цепочек не бывает)
+			if err := tx.Model(&models.ReferenceRedirect{}).
+				Where("entity = ? AND to_id IN ?", entity, sources).
+				Update("to_id", req.TargetID).Error; err != nil {
+				return err
+			}
+			for _, id := range sources {
+				redirect := models.ReferenceRedirect{Entity: entity, FromID: id, ToID: req.TargetID, MergeID: merge.ID}
+				if err := tx.Create(&redirect).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Where("id IN ?", sources).Delete(def.model).Error
+		})
+		if err != nil {
+			var invalid errMergeInvalid
+			if errors.As(err, &invalid) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": invalid.reason})
+				return
+			}
+			logger.Error("failed to merge", "entity", entity, "error", err)
+			http.Error(w, `{"error": "failed to merge"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "merged successfully",
+			"merge":   mergeView(merge),
+		})
+
+		invalidateReferences(entity)
+		recordAudit(db, logger, r, entity+".merge", entity, req.TargetID,
+			map[string]interface{}{"sources": found},
+			map[string]interface{}{
+				"target":                    target,
+				"ads_moved":                 merge.AdsMoved,
+				"worker_links_moved":        merge.WorkerLinksMoved,
+				"worker_links_deduplicated": merge.WorkerLinksDeduplicated,
+			})
+
+		logger.Info("Справочник слит", "entity", entity, "sources", sources, "target", req.TargetID, "ads_moved", merge.AdsMoved)
+	}
+}
+
+// mergeReferences переносит ссылки на источники в цель и возвращает счётчики
+func mergeReferences(tx *gorm.DB, entity string, sources []uint, target uint) (models.ReferenceMerge, error) {
+	merge := models.ReferenceMerge{Entity: entity, TargetID: target}
+
+	// Объявления переносятся вместе с удалёнными, чтобы их можно было восстановить
+	column := "category_id"
+	if entity == storage.RedirectPriceUnit {
+		column = "price_unit_id"
+	}
+	result := tx.Unscoped().Model(&models.Ad{}).Where(column+" IN ?", sources).
+		Updates(map[string]interface{}{column: target, "version": storage.NextVersion()})
+	if result.Error != nil {
+		return merge, result.Error
+	}
+	merge.AdsMoved = result.RowsAffected
+
+	if entity != storage.RedirectCategory {
+		// предложения по откликам остаются в единице цены своего объявления
+		return merge, tx.Model(&models.Offer{}).Where("price_unit_id IN ?", sources).Update("price_unit_id", target).Error
+	}
+
+	// Цель не может быть подкатегорией источника: подкатегории источников переходят к цели
+	descendants, err := storage.CategoryDescendantIDs(tx, sources...)
+	if err != nil {
+		return merge, err
+	}
+	for _, id := range descendants {
+		if id == target {
+			return merge, errMergeInvalid{"target cannot be a subcategory of a source category"}
+		}
+	}
+	if err := tx.Model(&models.Category{}).Where("parent_id IN ?", sources).Update("parent_id", target).Error; err != nil {
+		return merge, err
+	}
+
+	// Связи мастеров: у кого уже есть цель, связь с источником просто удаляется
+	if err := tx.Model(&models.WorkerProfile{}).
+		Where("user_id IN (?)", tx.Model(&models.WorkerCategory{}).Select("worker_id").Where("category_id IN ?", sources)).
+		Update("version", storage.NextVersion()).Error; err != nil {
+		return merge, err
+	}
+	var links int64
+	if err := tx.Model(&models.WorkerCategory{}).Where("category_id IN ?", sources).Count(&links).Error; err != nil {
+		return merge, err
+	}
+	result = tx.Exec(`INSERT INTO worker_categories (worker_id, category_id)
+		SELECT DISTINCT worker_id, ? FROM worker_categories WHERE category_id IN ?
+		ON CONFLICT DO NOTHING`, target, sources)
+	if result.Error != nil {
+		return merge, result.Error
+	}
+	merge.WorkerLinksMoved = result.RowsAffected
+	merge.WorkerLinksDeduplicated = links - result.RowsAffected
+	if err := tx.Where("category_id IN ?", sources).Delete(&models.WorkerCategory{}).Error; err != nil {
+		return merge, err
+	}
+
+	if err := tx.Model(&models.AdFirstResponse{}).Where("category_id IN ?", sources).Update("category_id", target).Error; err != nil {
+		return merge, err
+	}
+	if err := tx.Where("category_id IN ?", sources).Delete(&models.CategoryTranslation{}).Error; err != nil {
+		return merge, err
+	}
+
+	return merge, nil
+}
+
+func mergeView(m models.ReferenceMerge) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                        m.ID,
+		"entity":                    m.Entity,
+		"sources":                   rawJSON(m.SourceNames),
+		"target_id":                 m.TargetID,
+		"ads_moved":                 m.AdsMoved,
+		"worker_links_moved":        m.WorkerLinksMoved,
+		"worker_links_deduplicated": m.WorkerLinksDeduplicated,
+		"merged_by":                 m.MergedBy,
+		"created_at":                m.CreatedAt,
+	}
+}
+
+// GetMergesHandler - история слияний справочников (?entity=category|price_unit&target_id=)
+func GetMergesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		query := db.Model(&models.ReferenceMerge{})
+		if entity := r.URL.Query().Get("entity"); entity != "" {
+			query = query.Where("entity = ?", entity)
+		}
+		if targetID := r.URL.Query().Get("target_id"); targetID != "" {
+			query = query.Where("target_id = ?", targetID)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var merges []models.ReferenceMerge
+		if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&merges).Error; err != nil {
+			logger.Error("failed to get merges", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		views := make([]map[string]interface{}, len(merges))
+		for i, m := range merges {
+			views[i] = mergeView(m)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"merges": views,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
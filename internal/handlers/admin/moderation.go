@@ -2,7 +2,13 @@ package admin
 
 import (
 	"encoding/json"
+	"errors"
+	"go-api/internal/cache"
+	"go-api/internal/metrics"
 	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -12,9 +18,53 @@ import (
 	"gorm.io/gorm"
 )
 
+type adInfo struct {
+	ID             uint      `json:"id"`
+	Title          string    `json:"title"`
+	Price          float64   `json:"price"`
+	Location       string    `json:"location"`
+	CreatedAt      time.Time `json:"created_at"`
+	CategoryName   string    `json:"category_name"`
+	PriceUnitName  string    `json:"price_unit_name"`
+	UserID         uint      `json:"user_id"`
+	UserName       string    `json:"user_name"`
+	UserEmail      string    `json:"user_email"`
+	ResponsesCount int64     `json:"responses_count"`
+	Status         string    `json:"status"`
+}
+
+// adsQuery - запрос списка объявлений с фильтрами (?category=&user_id=&status=), общий для списка и экспорта
+func adsQuery(db *gorm.DB, r *http.Request) *gorm.DB {
+	query := db.Table("ads a").
+		Select("a.id, a.title, a.price, a.location, a.created_at, a.status, " +
+			"c.name as category_name, pu.name as price_unit_name, " +
+			"u.id as user_id, u.name as user_name, u.email as user_email, " +
+			"COUNT(r.id) as responses_count").
+		Joins("JOIN categories c ON a.category_id = c.id").
+		Joins("JOIN price_units pu ON a.price_unit_id = pu.id").
+		Joins("JOIN users u ON a.user_id = u.id").
+		Joins("LEFT JOIN responses r ON a.id = r.ad_id AND r.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL").
+		Group("a.id, a.title, a.price, a.location, a.created_at, a.status, c.name, pu.name, u.id, u.name, u.email").
+		Order("a.created_at DESC")
+
+	// Фильтры
+	if category := r.URL.Query().Get("category"); category != "" {
+		query = query.Where("c.name ILIKE ?", "%"+category+"%")
+	}
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		query = query.Where("a.user_id = ?", userID)
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("a.status = ?", status)
+	}
+	return query
+}
+
 // GetAllAdsHandler - получить все объявления (с фильтрами)
 func GetAllAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		limit := 10
@@ -26,48 +76,11 @@ func GetAllAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			offset, _ = strconv.Atoi(o)
 		}
 
-		type AdInfo struct {
-			ID             uint      `json:"id"`
-			Title          string    `json:"title"`
-			Price          float64   `json:"price"`
-			Location       string    `json:"location"`
-			CreatedAt      time.Time `json:"created_at"`
-			CategoryName   string    `json:"category_name"`
-			PriceUnitName  string    `json:"price_unit_name"`
-			UserID         uint      `json:"user_id"`
-			UserName       string    `json:"user_name"`
-			UserEmail      string    `json:"user_email"`
-			ResponsesCount int64     `json:"responses_count"`
-			Status         string    `json:"status"`
-		}
-
-		var ads []AdInfo
-		query := db.Table("ads a").
-			Select("a.id, a.title, a.price, a.location, a.created_at, a.status, " +
-				"c.name as category_name, pu.name as price_unit_name, " +
-				"u.id as user_id, u.name as user_name, u.email as user_email, " +
-				"COUNT(r.id) as responses_count").
-			Joins("JOIN categories c ON a.category_id = c.id").
-			Joins("JOIN price_units pu ON a.price_unit_id = pu.id").
-			Joins("JOIN users u ON a.user_id = u.id").
-			Joins("LEFT JOIN responses r ON a.id = r.ad_id AND r.deleted_at IS NULL").
-			Where("a.deleted_at IS NULL").
-			Group("a.id, a.title, a.price, a.location, a.created_at, a.status, c.name, pu.name, u.id, u.name, u.email").
-			Order("a.created_at DESC").
+		var ads []adInfo
+		query := adsQuery(db, r).
 			Limit(limit).
 			Offset(offset)
 
-		// Фильтры
-		if category := r.URL.Query().Get("category"); category != "" {
-			query = query.Where("c.name ILIKE ?", "%"+category+"%")
-		}
-		if userID := r.URL.Query().Get("user_id"); userID != "" {
-			query = query.Where("a.user_id = ?", userID)
-		}
-		if status := r.URL.Query().Get("status"); status != "" {
-			query = query.Where("a.status = ?", status)
-		}
-
 		var total int64
 		db.Model(&models.Ad{}).Count(&total)
 
@@ -89,6 +102,7 @@ func GetAllAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // DeleteAdHandler - удалить объявление
 func DeleteAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		adIDStr := chi.URLParam(r, "adID")
@@ -104,12 +118,18 @@ func DeleteAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if err := db.Delete(&ad).Error; err != nil {
+		if err := storage.DeleteAd(db, &ad); err != nil {
 			logger.Error("failed to delete ad", "error", err)
 			http.Error(w, `{"error": "failed to delete ad"}`, http.StatusInternalServerError)
 			return
 		}
 
+		recordAudit(db, logger, r, "ad.delete", "ad", ad.ID, map[string]interface{}{
+			"title":   ad.Title,
+			"user_id": ad.UserID,
+			"status":  ad.Status,
+		}, nil)
+
 		logger.Info("ad deleted by admin", "ad_id", adID)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "ad deleted successfully",
@@ -117,9 +137,50 @@ func DeleteAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	}
 }
 
+type responseInfo struct {
+	ID            uint      `json:"id"`
+	AdID          uint      `json:"ad_id"`
+	AdTitle       string    `json:"ad_title"`
+	WorkerID      uint      `json:"worker_id"`
+	WorkerName    string    `json:"worker_name"`
+	WorkerEmail   string    `json:"worker_email"`
+	Message       string    `json:"message"`
+	ProposedPrice *float64  `json:"proposed_price"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	ModerationStatus string `json:"moderation_status"`
+}
+
+// responsesQuery - запрос списка откликов с фильтрами (?status=&worker_id=&moderation_status=),
+// общий для списка и экспорта
+func responsesQuery(db *gorm.DB, r *http.Request) *gorm.DB {
+	query := db.Table("responses r").
+		Select("r.id, r.ad_id, r.worker_id, r.message, r.proposed_price, r.status, r.created_at, r.moderation_status, " +
+			"a.title as ad_title, " +
+			"u.name as worker_name, u.email as worker_email").
+		Joins("JOIN ads a ON r.ad_id = a.id").
+		Joins("JOIN users u ON r.worker_id = u.id").
+		Where("r.deleted_at IS NULL").
+		Order("r.created_at DESC")
+
+	// Фильтры
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("r.status = ?", status)
+	}
+	if workerID := r.URL.Query().Get("worker_id"); workerID != "" {
+		query = query.Where("r.worker_id = ?", workerID)
+	}
+	if moderationStatus := r.URL.Query().Get("moderation_status"); moderationStatus != "" {
+		query = query.Where("r.moderation_status = ?", moderationStatus)
+	}
+	return query
+}
+
 // GetAllResponsesHandler - получить все отклики (с фильтрами)
 func GetAllResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		limit := 10
@@ -131,39 +192,11 @@ func GetAllResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			offset, _ = strconv.Atoi(o)
 		}
 
-		type ResponseInfo struct {
-			ID            uint      `json:"id"`
-			AdID          uint      `json:"ad_id"`
-			AdTitle       string    `json:"ad_title"`
-			WorkerID      uint      `json:"worker_id"`
-			WorkerName    string    `json:"worker_name"`
-			WorkerEmail   string    `json:"worker_email"`
-			Message       string    `json:"message"`
-			ProposedPrice *float64  `json:"proposed_price"`
-			Status        string    `json:"status"`
-			CreatedAt     time.Time `json:"created_at"`
-		}
-
-		var responses []ResponseInfo
-		query := db.Table("responses r").
-			Select("r.id, r.ad_id, r.worker_id, r.message, r.proposed_price, r.status, r.created_at, " +
-				"a.title as ad_title, " +
-				"u.name as worker_name, u.email as worker_email").
-			Joins("JOIN ads a ON r.ad_id = a.id").
-			Joins("JOIN users u ON r.worker_id = u.id").
-			Where("r.deleted_at IS NULL").
-			Order("r.created_at DESC").
+		var responses []responseInfo
+		query := responsesQuery(db, r).
 			Limit(limit).
 			Offset(offset)
 
-		// Фильтры
-		if status := r.URL.Query().Get("status"); status != "" {
-			query = query.Where("r.status = ?", status)
-		}
-		if workerID := r.URL.Query().Get("worker_id"); workerID != "" {
-			query = query.Where("r.worker_id = ?", workerID)
-		}
-
 		var total int64
 		db.Model(&models.Response{}).Count(&total)
 
@@ -185,6 +218,7 @@ func GetAllResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // DeleteResponseHandler - удалить отклик
 func DeleteResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		responseIDStr := chi.URLParam(r, "responseID")
@@ -200,12 +234,18 @@ func DeleteResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if err := db.Delete(&response).Error; err != nil {
+		if err := storage.DeleteResponse(db, &response); err != nil {
 			logger.Error("failed to delete response", "error", err)
 			http.Error(w, `{"error": "failed to delete response"}`, http.StatusInternalServerError)
 			return
 		}
 
+		recordAudit(db, logger, r, "response.delete", "response", response.ID, map[string]interface{}{
+			"ad_id":     response.AdID,
+			"worker_id": response.WorkerID,
+			"status":    response.Status,
+		}, nil)
+
 		logger.Info("response deleted by admin", "response_id", responseID)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "response deleted successfully",
@@ -216,6 +256,7 @@ func DeleteResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // GetStatsHandler - получить статистику платформы
 func GetStatsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, _ := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		type Stats struct {
@@ -287,6 +328,7 @@ func GetStatsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // GetBlacklistHandler - получить черный список
 func GetBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		var blacklist []models.BlackList
@@ -306,6 +348,7 @@ func GetBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // AddToBlacklistHandler - добавить email в черный список
 func AddToBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		type BlacklistRequest struct {
@@ -330,6 +373,8 @@ func AddToBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		recordAudit(db, logger, r, "blacklist.add", "blacklist", req.Email, nil, blacklistEntry)
+
 		logger.Info("email added to blacklist by admin", "email", req.Email)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "email added to blacklist",
@@ -341,6 +386,7 @@ func AddToBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // RemoveFromBlacklistHandler - удалить email из черного списка
 func RemoveFromBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		email := chi.URLParam(r, "email")
@@ -361,6 +407,8 @@ func RemoveFromBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFu
 			return
 		}
 
+		recordAudit(db, logger, r, "blacklist.remove", "blacklist", email, blacklistEntry, nil)
+
 		logger.Info("email removed from blacklist by admin", "email", email)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "email removed from blacklist",
@@ -373,9 +421,144 @@ func RemoveFromBlacklistHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFu
 // МОДЕРАЦИЯ — ОДОБРЕНИЕ / ОТКЛОНЕНИЕ
 // ======================================================================
 
+// GetModerationDecisionsHandler - решения автомодерации (?entity_type=ad|response&entity_id=&decision=)
+func GetModerationDecisionsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		query := db.Model(&models.ModerationDecision{})
+		if entityType := r.URL.Query().Get("entity_type"); entityType != "" {
+			query = query.Where("entity_type = ?", entityType)
+		}
+		if entityID := r.URL.Query().Get("entity_id"); entityID != "" {
+			query = query.Where("entity_id = ?", entityID)
+		}
+		if decision := r.URL.Query().Get("decision"); decision != "" {
+			query = query.Where("decision = ?", decision)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var decisions []models.ModerationDecision
+		if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&decisions).Error; err != nil {
+			logger.Error("failed to get moderation decisions", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		type DecisionInfo struct {
+			ID         uint            `json:"id"`
+			EntityType string          `json:"entity_type"`
+			EntityID   uint            `json:"entity_id"`
+			Decision   string          `json:"decision"`
+			Rules      json.RawMessage `json:"rules"`
+			CreatedAt  time.Time       `json:"created_at"`
+		}
+
+		result := make([]DecisionInfo, len(decisions))
+		for i, d := range decisions {
+			result[i] = DecisionInfo{
+				ID:         d.ID,
+				EntityType: d.EntityType,
+				EntityID:   d.EntityID,
+				Decision:   d.Decision,
+				Rules:      rawJSON(d.Rules),
+				CreatedAt:  d.CreatedAt,
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"decisions": result,
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+		})
+	}
+}
+
+// transitionStatus переводит объявление или профиль мастера в статус to, только если сейчас он
+// в одном из статусов from ("одобрить, только если ещё pending"); updates меняются вместе со статусом.
+// Если статус успели изменить (другой модератор, жалоба, автор) - 409 с текущим статусом,
+// с If-Match меняется только указанная версия (иначе 412).
+// Возвращает прежний статус; done=false - ответ уже отправлен
+func transitionStatus(w http.ResponseWriter, r *http.Request, db *gorm.DB, logger *slog.Logger,
+	model interface{}, column string, id uint64, entity, to string, updates map[string]interface{}, from ...string) (prev string, done bool) {
+	version, _, ok := cache.OptionalIfMatch(w, r)
+	if !ok {
+		return "", false
+	}
+
+	return applyTransition(w, db, logger, entity, storage.Transition{
+		Model:   model,
+		Where:   column + " = ?",
+		Args:    []interface{}{id},
+		From:    from,
+		To:      to,
+		Version: version,
+		Updates: updates,
+	})
+}
+
+// transitionModeration меняет статус модерации отклика (у откликов нет версии, If-Match не нужен)
+func transitionModeration(w http.ResponseWriter, db *gorm.DB, logger *slog.Logger, id uint64, to string, from ...string) (prev string, done bool) {
+	return applyTransition(w, db, logger, "response", storage.Transition{
+		Model:       &models.Response{},
+		Column:      "moderation_status",
+		Unversioned: true,
+		Where:       "id = ?",
+		Args:        []interface{}{id},
+		From:        from,
+		To:          to,
+	})
+}
+
+// applyTransition выполняет переход и при ошибке отправляет ответ 404/409/412/500
+func applyTransition(w http.ResponseWriter, db *gorm.DB, logger *slog.Logger, entity string, t storage.Transition) (prev string, done bool) {
+	prev, err := storage.TransitionStatus(db, t)
+	to := t.To
+	var conflict *storage.StatusConflict
+	var mismatch *storage.VersionMismatch
+	switch {
+	case err == nil:
+		return prev, true
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, `{"error": "`+entity+` not found"}`, http.StatusNotFound)
+	case errors.As(err, &mismatch):
+		cache.PreconditionFailed(w, mismatch.Version)
+	case errors.As(err, &conflict):
+		cache.StatusConflict(w, entity, to, conflict.Status, conflict.Version)
+	default:
+		logger.Error("failed to change "+entity+" status", "error", err, "status", to)
+		http.Error(w, `{"error": "failed to change `+entity+` status"}`, http.StatusInternalServerError)
+	}
+	return "", false
+}
+
+// errResponded - ответ уже отправлен (transitionStatus внутри транзакции), транзакция откатывается
+var errResponded = errors.New("response already written")
+
+// Статусы, из которых администратор может отклонить объявление или профиль мастера
+// (вручную или разбирая жалобу)
+var (
+	adRejectFrom     = []string{models.AdStatusPending, models.AdStatusActive, models.AdStatusPaused}
+	workerRejectFrom = []string{"pending", "approved"}
+)
+
 // ApproveAdHandler - одобрить объявление
 func ApproveAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		adIDStr := chi.URLParam(r, "adID")
@@ -385,22 +568,24 @@ func ApproveAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		result := db.Model(&models.Ad{}).Where("id = ?", adID).Update("status", "approved")
-		if result.Error != nil {
-			logger.Error("failed to approve ad", "error", result.Error)
-			http.Error(w, `{"error": "failed to approve ad"}`, http.StatusInternalServerError)
+		prev, done := transitionStatus(w, r, db, logger, &models.Ad{}, "id", adID, "ad", models.AdStatusActive,
+			storage.AdPublication(time.Now()), models.AdStatusPending)
+		if !done {
 			return
 		}
-		if result.RowsAffected == 0 {
-			http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
-			return
+
+		recordAudit(db, logger, r, "ad.approve", "ad", adID,
+			map[string]string{"status": prev}, map[string]string{"status": models.AdStatusActive})
+		if err := moderation.RecordManual(db, moderation.EntityAd, uint(adID), moderation.ActionApprove); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "ad_id", adID)
 		}
 
+		metrics.ModerationDecisions.WithLabelValues("ad", "approve", "manual").Inc()
 		logger.Info("ad approved by admin", "ad_id", adID)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "ad approved successfully",
 			"ad_id":   adID,
-			"status":  "approved",
+			"status":  models.AdStatusActive,
 		})
 	}
 }
@@ -408,6 +593,7 @@ func ApproveAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // RejectAdHandler - отклонить объявление
 func RejectAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		adIDStr := chi.URLParam(r, "adID")
@@ -417,17 +603,31 @@ func RejectAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		result := db.Model(&models.Ad{}).Where("id = ?", adID).Update("status", "rejected")
-		if result.Error != nil {
-			logger.Error("failed to reject ad", "error", result.Error)
-			http.Error(w, `{"error": "failed to reject ad"}`, http.StatusInternalServerError)
+		// отклонённое объявление снимается с публикации: торг закрывается, брони мастеров отменяются
+		var prev string
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var done bool
+			if prev, done = transitionStatus(w, r, tx, logger, &models.Ad{}, "id", adID, "ad", models.AdStatusRejected, nil, adRejectFrom...); !done {
+				return errResponded
+			}
+			return storage.ReleaseAd(tx, uint(adID))
+		})
+		if errors.Is(err, errResponded) {
 			return
 		}
-		if result.RowsAffected == 0 {
-			http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
+		if err != nil {
+			logger.Error("failed to reject ad", "error", err, "ad_id", adID)
+			http.Error(w, `{"error": "failed to reject ad"}`, http.StatusInternalServerError)
 			return
 		}
 
+		recordAudit(db, logger, r, "ad.reject", "ad", adID,
+			map[string]string{"status": prev}, map[string]string{"status": "rejected"})
+		if err := moderation.RecordManual(db, moderation.EntityAd, uint(adID), moderation.ActionReject); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "ad_id", adID)
+		}
+
+		metrics.ModerationDecisions.WithLabelValues("ad", "reject", "manual").Inc()
 		logger.Info("ad rejected by admin", "ad_id", adID)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "ad rejected successfully",
@@ -437,9 +637,77 @@ func RejectAdHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	}
 }
 
+// ApproveResponseHandler - одобрить отклик, задержанный или отклонённый автомодерацией:
+// он становится виден владельцу объявления
+func ApproveResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		responseID, err := strconv.ParseUint(chi.URLParam(r, "responseID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid response id"}`, http.StatusBadRequest)
+			return
+		}
+
+		prev, done := transitionModeration(w, db, logger, responseID, "approved", "pending", "rejected")
+		if !done {
+			return
+		}
+
+		recordAudit(db, logger, r, "response.approve", "response", responseID,
+			map[string]string{"moderation_status": prev}, map[string]string{"moderation_status": "approved"})
+		if err := moderation.RecordManual(db, moderation.EntityResponse, uint(responseID), moderation.ActionApprove); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "response_id", responseID)
+		}
+
+		metrics.ModerationDecisions.WithLabelValues("response", "approve", "manual").Inc()
+		logger.Info("response approved by admin", "response_id", responseID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           "response approved successfully",
+			"response_id":       responseID,
+			"moderation_status": "approved",
+		})
+	}
+}
+
+// RejectResponseHandler - отклонить отклик: владелец объявления его больше не видит
+func RejectResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		responseID, err := strconv.ParseUint(chi.URLParam(r, "responseID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid response id"}`, http.StatusBadRequest)
+			return
+		}
+
+		prev, done := transitionModeration(w, db, logger, responseID, "rejected", "pending", "approved")
+		if !done {
+			return
+		}
+
+		recordAudit(db, logger, r, "response.reject", "response", responseID,
+			map[string]string{"moderation_status": prev}, map[string]string{"moderation_status": "rejected"})
+		if err := moderation.RecordManual(db, moderation.EntityResponse, uint(responseID), moderation.ActionReject); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "response_id", responseID)
+		}
+
+		metrics.ModerationDecisions.WithLabelValues("response", "reject", "manual").Inc()
+		logger.Info("response rejected by admin", "response_id", responseID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           "response rejected successfully",
+			"response_id":       responseID,
+			"moderation_status": "rejected",
+		})
+	}
+}
+
 // ApproveWorkerHandler - одобрить профиль мастера
 func ApproveWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		workerIDStr := chi.URLParam(r, "workerID")
@@ -449,17 +717,15 @@ func ApproveWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		result := db.Model(&models.WorkerProfile{}).Where("user_id = ?", workerID).Update("status", "approved")
-		if result.Error != nil {
-			logger.Error("failed to approve worker", "error", result.Error)
-			http.Error(w, `{"error": "failed to approve worker profile"}`, http.StatusInternalServerError)
-			return
-		}
-		if result.RowsAffected == 0 {
-			http.Error(w, `{"error": "worker profile not found"}`, http.StatusNotFound)
+		prev, done := transitionStatus(w, r, db, logger, &models.WorkerProfile{}, "user_id", workerID, "worker profile", "approved", nil, "pending")
+		if !done {
 			return
 		}
 
+		recordAudit(db, logger, r, "worker.approve", "worker", workerID,
+			map[string]string{"status": prev}, map[string]string{"status": "approved"})
+
+		metrics.ModerationDecisions.WithLabelValues("worker", "approve", "manual").Inc()
 		logger.Info("worker profile approved by admin", "worker_id", workerID)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message":   "worker profile approved successfully",
@@ -472,6 +738,7 @@ func ApproveWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // RejectWorkerHandler - отклонить профиль мастера
 func RejectWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		workerIDStr := chi.URLParam(r, "workerID")
@@ -481,17 +748,15 @@ func RejectWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		result := db.Model(&models.WorkerProfile{}).Where("user_id = ?", workerID).Update("status", "rejected")
-		if result.Error != nil {
-			logger.Error("failed to reject worker", "error", result.Error)
-			http.Error(w, `{"error": "failed to reject worker profile"}`, http.StatusInternalServerError)
-			return
-		}
-		if result.RowsAffected == 0 {
-			http.Error(w, `{"error": "worker profile not found"}`, http.StatusNotFound)
+		prev, done := transitionStatus(w, r, db, logger, &models.WorkerProfile{}, "user_id", workerID, "worker profile", "rejected", nil, workerRejectFrom...)
+		if !done {
 			return
 		}
 
+		recordAudit(db, logger, r, "worker.reject", "worker", workerID,
+			map[string]string{"status": prev}, map[string]string{"status": "rejected"})
+
+		metrics.ModerationDecisions.WithLabelValues("worker", "reject", "manual").Inc()
 		logger.Info("worker profile rejected by admin", "worker_id", workerID)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message":   "worker profile rejected successfully",
@@ -504,6 +769,7 @@ func RejectWorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // GetPendingWorkersHandler - список профилей мастеров на модерации
 func GetPendingWorkersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		status := r.URL.Query().Get("status")
@@ -2,27 +2,134 @@ package admin
 
 import (
 	"encoding/json"
+	"go-api/internal/cache"
 	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
+// invalidateReferences сбрасывает кэш /info/* после изменения справочников
+func invalidateReferences(entities ...string) {
+	for _, entity := range entities {
+		switch entity {
+		case "category":
+			cache.Invalidate(cache.Categories)
+		case "price_unit":
+			cache.Invalidate(cache.PriceUnits)
+		}
+	}
+}
+
 // ======================================================================
 // КАТЕГОРИИ
 // ======================================================================
 
+// categoryRequest - поля категории; в PATCH передаются только изменяемые
+type categoryRequest struct {
+	Name      *string           `json:"name"`
+	Slug      *string           `json:"slug"`
+	ParentID  *uint             `json:"parent_id"` // 0 - сделать корневой
+	SortOrder *int              `json:"sort_order"`
+	Icon      *string           `json:"icon"`
+	Names     map[string]string `json:"names"` // названия по языкам: {"en": "Plumbing"}; заменяет все переводы
+}
+
+// applyCategoryRequest переносит поля запроса в категорию и проверяет слаг и родителя
+func applyCategoryRequest(db *gorm.DB, category *models.Category, req categoryRequest) (int, string) {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return http.StatusBadRequest, "Category name is required"
+		}
+		category.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		if !storage.ValidSlug(*req.Slug) {
+			return http.StatusBadRequest, "Invalid slug: use lowercase latin letters, digits and dashes"
+		}
+		var count int64
+		db.Unscoped().Model(&models.Category{}).Where("slug = ? AND id <> ?", *req.Slug, category.ID).Count(&count)
+		if count > 0 {
+			return http.StatusConflict, "Slug is already used"
+		}
+		category.Slug = *req.Slug
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			var parent models.Category
+			if err := db.First(&parent, *req.ParentID).Error; err != nil {
+				return http.StatusBadRequest, "Parent category not found"
+			}
+			// Родитель не может быть самой категорией или её потомком
+			if category.ID != 0 {
+				descendants, err := storage.CategoryDescendantIDs(db, category.ID)
+				if err != nil {
+					return http.StatusInternalServerError, "Database error"
+				}
+				for _, id := range descendants {
+					if id == parent.ID {
+						return http.StatusBadRequest, "Category cannot be moved under itself or its subcategory"
+					}
+				}
+			}
+			category.ParentID = &parent.ID
+		}
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+	if req.Icon != nil {
+		category.Icon = *req.Icon
+	}
+	return 0, ""
+}
+
+func saveCategoryTranslations(tx *gorm.DB, categoryID uint, names map[string]string) error {
+	if names == nil {
+		return nil
+	}
+	if err := tx.Where("category_id = ?", categoryID).Delete(&models.CategoryTranslation{}).Error; err != nil {
+		return err
+	}
+	for locale, name := range names {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		name = strings.TrimSpace(name)
+		if locale == "" || name == "" {
+			continue
+		}
+		t := models.CategoryTranslation{CategoryID: categoryID, Locale: locale, Name: name}
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func categoryAuditFields(c models.Category) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       c.Name,
+		"slug":       c.Slug,
+		"parent_id":  c.ParentID,
+		"sort_order": c.SortOrder,
+		"icon":       c.Icon,
+	}
+}
+
 // CreateCategoryHandler - создание новой категории
 func CreateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
-		var req struct {
-			Name string `json:"name"`
-		}
+		var req categoryRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -30,35 +137,61 @@ func CreateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if req.Name == "" {
+		if req.Name == nil || *req.Name == "" {
 			http.Error(w, "Category name is required", http.StatusBadRequest)
 			return
 		}
 
-		category := models.Category{
-			Name: req.Name,
+		var category models.Category
+		if status, msg := applyCategoryRequest(db, &category, req); status != 0 {
+			http.Error(w, msg, status)
+			return
 		}
 
-		if err := db.Create(&category).Error; err != nil {
+		// Слаг по умолчанию - транслитерация названия
+		if category.Slug == "" {
+			slug, err := storage.UniqueCategorySlug(db, category.Name)
+			if err != nil {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				logger.Error("Ошибка подбора слага категории", "error", err)
+				return
+			}
+			category.Slug = slug
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			return saveCategoryTranslations(tx, category.ID, req.Names)
+		})
+		if err != nil {
 			http.Error(w, "Failed to create category", http.StatusInternalServerError)
 			logger.Error("Ошибка создания категории", "error", err)
 			return
 		}
 
+		db.Preload("Translations").First(&category, category.ID)
+
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Category created successfully",
-			"id":      category.ID,
-			"name":    category.Name,
+			"message":  "Category created successfully",
+			"id":       category.ID,
+			"name":     category.Name,
+			"category": category,
 		})
 
-		logger.Info("Категория создана", "id", category.ID, "name", category.Name)
+		invalidateReferences("category")
+		recordAudit(db, logger, r, "category.create", "category", category.ID, nil, categoryAuditFields(category))
+
+		logger.Info("Категория создана", "id", category.ID, "name", category.Name, "slug", category.Slug)
 	}
 }
 
-// UpdateCategoryHandler - обновление категории
+// UpdateCategoryHandler - обновление категории (переименование не меняет слаг)
 func UpdateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		categoryIDStr := chi.URLParam(r, "categoryID")
@@ -68,9 +201,7 @@ func UpdateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		var req struct {
-			Name string `json:"name"`
-		}
+		var req categoryRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -78,11 +209,6 @@ func UpdateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if req.Name == "" {
-			http.Error(w, "Category name is required", http.StatusBadRequest)
-			return
-		}
-
 		var category models.Category
 		if err := db.First(&category, categoryID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
@@ -94,20 +220,36 @@ func UpdateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		category.Name = req.Name
+		before := categoryAuditFields(category)
+		if status, msg := applyCategoryRequest(db, &category, req); status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
 
-		if err := db.Save(&category).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&category).Error; err != nil {
+				return err
+			}
+			return saveCategoryTranslations(tx, category.ID, req.Names)
+		})
+		if err != nil {
 			http.Error(w, "Failed to update category", http.StatusInternalServerError)
 			logger.Error("Ошибка обновления категории", "error", err)
 			return
 		}
 
+		db.Preload("Translations").First(&category, category.ID)
+
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Category updated successfully",
-			"id":      category.ID,
-			"name":    category.Name,
+			"message":  "Category updated successfully",
+			"id":       category.ID,
+			"name":     category.Name,
+			"category": category,
 		})
 
+		invalidateReferences("category")
+		recordAudit(db, logger, r, "category.update", "category", category.ID, before, categoryAuditFields(category))
+
 		logger.Info("Категория обновлена", "id", category.ID, "name", category.Name)
 	}
 }
@@ -115,6 +257,7 @@ func UpdateCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // DeleteCategoryHandler - удаление категории
 func DeleteCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		categoryIDStr := chi.URLParam(r, "categoryID")
@@ -143,6 +286,14 @@ func DeleteCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		// Проверяем, есть ли подкатегории
+		var childrenCount int64
+		db.Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&childrenCount)
+		if childrenCount > 0 {
+			http.Error(w, "Cannot delete category: it has subcategories", http.StatusConflict)
+			return
+		}
+
 		// Проверяем, есть ли рабочие с этой категорией
 		var workerCategoriesCount int64
 		db.Model(&models.WorkerCategory{}).Where("category_id = ?", categoryID).Count(&workerCategoriesCount)
@@ -162,6 +313,9 @@ func DeleteCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			"message": "Category deleted successfully",
 		})
 
+		invalidateReferences("category")
+		recordAudit(db, logger, r, "category.delete", "category", category.ID, map[string]string{"name": category.Name}, nil)
+
 		logger.Info("Категория удалена", "id", categoryID)
 	}
 }
@@ -173,6 +327,7 @@ func DeleteCategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // CreatePriceUnitHandler - создание новой единицы цены
 func CreatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		var req struct {
@@ -207,6 +362,9 @@ func CreatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			"name":    priceUnit.Name,
 		})
 
+		invalidateReferences("price_unit")
+		recordAudit(db, logger, r, "price_unit.create", "price_unit", priceUnit.ID, nil, map[string]string{"name": priceUnit.Name})
+
 		logger.Info("Единица цены создана", "id", priceUnit.ID, "name", priceUnit.Name)
 	}
 }
@@ -214,6 +372,7 @@ func CreatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // UpdatePriceUnitHandler - обновление единицы цены
 func UpdatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		priceUnitIDStr := chi.URLParam(r, "priceUnitID")
@@ -249,6 +408,7 @@ func UpdatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		oldName := priceUnit.Name
 		priceUnit.Name = req.Name
 
 		if err := db.Save(&priceUnit).Error; err != nil {
@@ -263,6 +423,10 @@ func UpdatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			"name":    priceUnit.Name,
 		})
 
+		invalidateReferences("price_unit")
+		recordAudit(db, logger, r, "price_unit.update", "price_unit", priceUnit.ID,
+			map[string]string{"name": oldName}, map[string]string{"name": priceUnit.Name})
+
 		logger.Info("Единица цены обновлена", "id", priceUnit.ID, "name", priceUnit.Name)
 	}
 }
@@ -270,6 +434,7 @@ func UpdatePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // DeletePriceUnitHandler - удаление единицы цены
 func DeletePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		priceUnitIDStr := chi.URLParam(r, "priceUnitID")
@@ -309,6 +474,9 @@ func DeletePriceUnitHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			"message": "Price unit deleted successfully",
 		})
 
+		invalidateReferences("price_unit")
+		recordAudit(db, logger, r, "price_unit.delete", "price_unit", priceUnit.ID, map[string]string{"name": priceUnit.Name}, nil)
+
 		logger.Info("Единица цены удалена", "id", priceUnitID)
 	}
 }
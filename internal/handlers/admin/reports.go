@@ -0,0 +1,291 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"go-api/internal/cache"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// ЖАЛОБЫ ПОЛЬЗОВАТЕЛЕЙ
+// ======================================================================
+
+// Действия при разборе жалобы и объекты, к которым они применимы
+var reportActions = map[string][]string{
+	"dismiss":         {"ad", "worker", "response"},
+	"delete_ad":       {"ad"},
+	"reject_ad":       {"ad"},
+	"reject_worker":   {"worker"},
+	"delete_response": {"response"},
+	"blacklist":       {"ad", "worker", "response"},
+}
+
+// GetReportsHandler - список жалоб (?status=open|resolved|dismissed&target_type=&reason=)
+func GetReportsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = "open"
+		}
+
+		type ReportInfo struct {
+			ID            uint       `json:"id"`
+			ReporterID    uint       `json:"reporter_id"`
+			ReporterEmail string     `json:"reporter_email"`
+			TargetType    string     `json:"target_type"`
+			TargetID      uint       `json:"target_id"`
+			Reason        string     `json:"reason"`
+			Comment       string     `json:"comment"`
+			Status        string     `json:"status"`
+			Resolution    string     `json:"resolution,omitempty"`
+			ResolvedBy    *uint      `json:"resolved_by,omitempty"`
+			ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+			CreatedAt     time.Time  `json:"created_at"`
+			TargetReports int64      `json:"target_reports"` // сколько всего жалоб на этот объект
+		}
+
+		query := db.Table("reports rp").
+			Joins("JOIN users u ON rp.reporter_id = u.id").
+			Where("rp.deleted_at IS NULL AND rp.status = ?", status)
+
+		// Фильтры
+		if targetType := r.URL.Query().Get("target_type"); targetType != "" {
+			query = query.Where("rp.target_type = ?", targetType)
+		}
+		if targetID := r.URL.Query().Get("target_id"); targetID != "" {
+			query = query.Where("rp.target_id = ?", targetID)
+		}
+		if reason := r.URL.Query().Get("reason"); reason != "" {
+			query = query.Where("rp.reason = ?", reason)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var reports []ReportInfo
+		if err := query.
+			Select("rp.id, rp.reporter_id, u.email as reporter_email, rp.target_type, rp.target_id, rp.reason, rp.comment, " +
+				"rp.status, rp.resolution, rp.resolved_by, rp.resolved_at, rp.created_at, " +
+				"(SELECT COUNT(*) FROM reports x WHERE x.target_type = rp.target_type AND x.target_id = rp.target_id AND x.deleted_at IS NULL) as target_reports").
+			Order("target_reports DESC, rp.created_at ASC").
+			Limit(limit).
+			Offset(offset).
+			Scan(&reports).Error; err != nil {
+			logger.Error("failed to get reports", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"reports": reports,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+			"status":  status,
+		})
+	}
+}
+
+// ResolveReportHandler - разобрать жалобу. Действие применяется к объекту жалобы,
+// а все открытые жалобы на этот объект закрываются одним решением
+func ResolveReportHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		adminID, _ := r.Context().Value("user_id").(uint)
+
+		reportIDStr := chi.URLParam(r, "reportID")
+		reportID, err := strconv.ParseUint(reportIDStr, 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid report id"}`, http.StatusBadRequest)
+			return
+		}
+
+		type ResolveRequest struct {
+			Action  string `json:"action"`
+			Comment string `json:"comment"`
+		}
+
+		var req ResolveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		var report models.Report
+		if err := db.First(&report, uint(reportID)).Error; err != nil {
+			http.Error(w, `{"error": "report not found"}`, http.StatusNotFound)
+			return
+		}
+		if report.Status != "open" {
+			http.Error(w, `{"error": "report already resolved"}`, http.StatusConflict)
+			return
+		}
+
+		allowed := false
+		for _, t := range reportActions[req.Action] {
+			if t == report.TargetType {
+				allowed = true
+			}
+		}
+		if !allowed {
+			http.Error(w, `{"error": "action is not applicable to this report"}`, http.StatusBadRequest)
+			return
+		}
+
+		status := "resolved"
+		if req.Action == "dismiss" {
+			status = "dismissed"
+		}
+
+		var closed int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := applyReportAction(tx, req.Action, report.TargetType, report.TargetID); err != nil {
+				return err
+			}
+
+			now := time.Now()
+			result := tx.Model(&models.Report{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, "open").
+				Updates(map[string]interface{}{
+					"status":             status,
+					"resolution":         req.Action,
+					"resolution_comment": req.Comment,
+					"resolved_by":        adminID,
+					"resolved_at":        now,
+				})
+			closed = result.RowsAffected
+			return result.Error
+		})
+		var conflict *storage.StatusConflict
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, `{"error": "report target not found"}`, http.StatusNotFound)
+				return
+			}
+			// объект жалобы уже не в том статусе, из которого его можно отклонить
+			if errors.As(err, &conflict) {
+				entity := "ad"
+				if report.TargetType == "worker" {
+					entity = "worker profile"
+				}
+				cache.StatusConflict(w, entity, "rejected", conflict.Status, conflict.Version)
+				return
+			}
+			logger.Error("failed to resolve report", "error", err, "report_id", reportID)
+			http.Error(w, `{"error": "failed to resolve report"}`, http.StatusInternalServerError)
+			return
+		}
+
+		recordAudit(db, logger, r, "report.resolve", "report", reportID,
+			map[string]string{"status": report.Status},
+			map[string]interface{}{"status": status, "resolution": req.Action, "comment": req.Comment, "reports_closed": closed})
+
+		logger.Info("report resolved by admin",
+			"report_id", reportID,
+			"admin_id", adminID,
+			"action", req.Action,
+			"target_type", report.TargetType,
+			"target_id", report.TargetID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":        "report resolved",
+			"report_id":      reportID,
+			"status":         status,
+			"action":         req.Action,
+			"reports_closed": closed,
+		})
+	}
+}
+
+// applyReportAction выполняет действие администратора над объектом жалобы
+// (те же операции, что DeleteAdHandler, RejectAdHandler, RejectWorkerHandler,
+// DeleteResponseHandler и AddToBlacklistHandler)
+func applyReportAction(tx *gorm.DB, action, targetType string, targetID uint) error {
+	switch action {
+	case "dismiss":
+		return nil
+	case "delete_ad":
+		var ad models.Ad
+		if err := tx.First(&ad, targetID).Error; err != nil {
+			return err
+		}
+		return storage.DeleteAd(tx, &ad)
+	case "reject_ad":
+		if _, err := storage.TransitionStatus(tx, storage.Transition{
+			Model: &models.Ad{},
+			Where: "id = ?",
+			Args:  []interface{}{targetID},
+			From:  adRejectFrom,
+			To:    models.AdStatusRejected,
+		}); err != nil {
+			return err
+		}
+		return storage.ReleaseAd(tx, targetID)
+	case "reject_worker":
+		_, err := storage.TransitionStatus(tx, storage.Transition{
+			Model: &models.WorkerProfile{},
+			Where: "user_id = ?",
+			Args:  []interface{}{targetID},
+			From:  workerRejectFrom,
+			To:    "rejected",
+		})
+		return err
+	case "delete_response":
+		var response models.Response
+		if err := tx.First(&response, targetID).Error; err != nil {
+			return err
+		}
+		return storage.DeleteResponse(tx, &response)
+	case "blacklist":
+		ownerID, err := reportTargetOwner(tx, targetType, targetID)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.Select("id, email").First(&user, ownerID).Error; err != nil {
+			return err
+		}
+		entry := models.BlackList{Email: user.Email}
+		return tx.Where("email = ?", user.Email).FirstOrCreate(&entry).Error
+	}
+	return nil
+}
+
+func reportTargetOwner(tx *gorm.DB, targetType string, targetID uint) (uint, error) {
+	switch targetType {
+	case "ad":
+		var ad models.Ad
+		err := tx.Unscoped().Select("id, user_id").First(&ad, targetID).Error
+		return ad.UserID, err
+	case "response":
+		var response models.Response
+		err := tx.Unscoped().Select("id, worker_id").First(&response, targetID).Error
+		return response.WorkerID, err
+	default:
+		// worker: target_id - это user_id мастера
+		return targetID, nil
+	}
+}
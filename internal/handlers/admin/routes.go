@@ -3,41 +3,68 @@ package admin
 import (
 	"go-api/internal/middleware"
 	"log/slog"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
+func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router, exportTimeout time.Duration) {
 	admin := chi.NewRouter()
 
 	// Защита: требуется аутентификация + роль администратора
-	admin.Use(middleware.AuthMiddleware(logger))
+	admin.Use(middleware.AuthMiddleware(db, logger))
 	admin.Use(middleware.AdminMiddleware(db, logger))
+	admin.Use(middleware.Idempotency(db, logger)) // POST с заголовком Idempotency-Key
 
 	// Управление пользователями
-	admin.Get("/users", GetUsersHandler(db, logger))                       // GET /admin/users - список пользователей
-	admin.Get("/users/{userID}", GetUserHandler(db, logger))               // GET /admin/users/123 - пользователь по ID
-	admin.Delete("/users/{userID}", DeleteUserHandler(db, logger))         // DELETE /admin/users/123 - удалить пользователя
-	admin.Patch("/users/{userID}/role", UpdateUserRoleHandler(db, logger)) // PATCH /admin/users/123/role - изменить роль
+	admin.Get("/users", GetUsersHandler(db, logger))                          // GET /admin/users - список пользователей
+	admin.Get("/users/export", ExportUsersHandler(db, logger, exportTimeout)) // GET /admin/users/export - выгрузка (?format=csv|xlsx)
+	admin.Get("/users/{userID}", GetUserHandler(db, logger))                  // GET /admin/users/123 - пользователь по ID
+	admin.Delete("/users/{userID}", DeleteUserHandler(db, logger))            // DELETE /admin/users/123 - удалить пользователя
+	admin.Patch("/users/{userID}/role", UpdateUserRoleHandler(db, logger))    // PATCH /admin/users/123/role - изменить роль
+
+	// Ограничения аккаунтов
+	admin.Post("/users/{userID}/suspensions", SuspendUserHandler(db, logger))          // POST /admin/users/123/suspensions - ограничить аккаунт
+	admin.Get("/suspensions", GetSuspensionsHandler(db, logger))                       // GET /admin/suspensions - список ограничений (?active=true)
+	admin.Patch("/suspensions/{suspensionID}/lift", LiftSuspensionHandler(db, logger)) // PATCH /admin/suspensions/123/lift - снять ограничение
 
 	// Модерация объявлений
-	admin.Get("/ads", GetAllAdsHandler(db, logger))                  // GET /admin/ads - все объявления (?status=pending|approved|rejected)
-	admin.Delete("/ads/{adID}", DeleteAdHandler(db, logger))         // DELETE /admin/ads/123 - удалить объявление
-	admin.Patch("/ads/{adID}/approve", ApproveAdHandler(db, logger)) // PATCH /admin/ads/123/approve - одобрить объявление
-	admin.Patch("/ads/{adID}/reject", RejectAdHandler(db, logger))   // PATCH /admin/ads/123/reject - отклонить объявление
+	admin.Get("/ads", GetAllAdsHandler(db, logger))                       // GET /admin/ads - все объявления (?status=pending|approved|rejected)
+	admin.Get("/ads/export", ExportAdsHandler(db, logger, exportTimeout)) // GET /admin/ads/export - выгрузка (?format=csv|xlsx)
+	admin.Delete("/ads/{adID}", DeleteAdHandler(db, logger))              // DELETE /admin/ads/123 - удалить объявление
+	admin.Patch("/ads/{adID}/approve", ApproveAdHandler(db, logger))      // PATCH /admin/ads/123/approve - одобрить объявление
+	admin.Patch("/ads/{adID}/reject", RejectAdHandler(db, logger))        // PATCH /admin/ads/123/reject - отклонить объявление
+
+	// Автомодерация
+	admin.Get("/moderation/decisions", GetModerationDecisionsHandler(db, logger)) // GET /admin/moderation/decisions - решения автомодерации
 
 	// Модерация откликов
-	admin.Get("/responses", GetAllResponsesHandler(db, logger))                // GET /admin/responses - все отклики
-	admin.Delete("/responses/{responseID}", DeleteResponseHandler(db, logger)) // DELETE /admin/responses/123 - удалить отклик
+	admin.Get("/responses", GetAllResponsesHandler(db, logger))                        // GET /admin/responses - все отклики
+	admin.Get("/responses/export", ExportResponsesHandler(db, logger, exportTimeout))  // GET /admin/responses/export - выгрузка (?format=csv|xlsx)
+	admin.Delete("/responses/{responseID}", DeleteResponseHandler(db, logger))         // DELETE /admin/responses/123 - удалить отклик
+	admin.Patch("/responses/{responseID}/approve", ApproveResponseHandler(db, logger)) // PATCH /admin/responses/123/approve - одобрить отклик
+	admin.Patch("/responses/{responseID}/reject", RejectResponseHandler(db, logger))   // PATCH /admin/responses/123/reject - отклонить отклик
 
 	// Модерация профилей мастеров
 	admin.Get("/workers", GetPendingWorkersHandler(db, logger))                  // GET /admin/workers - профили мастеров (?status=pending|approved|rejected)
 	admin.Patch("/workers/{workerID}/approve", ApproveWorkerHandler(db, logger)) // PATCH /admin/workers/123/approve - одобрить профиль
 	admin.Patch("/workers/{workerID}/reject", RejectWorkerHandler(db, logger))   // PATCH /admin/workers/123/reject - отклонить профиль
 
+	// Жалобы пользователей
+	admin.Get("/reports", GetReportsHandler(db, logger))                         // GET /admin/reports - жалобы (?status=open|resolved|dismissed)
+	admin.Patch("/reports/{reportID}/resolve", ResolveReportHandler(db, logger)) // PATCH /admin/reports/123/resolve - разобрать жалобу
+
+	// Удалённые записи
+	admin.Get("/deleted/{entity}", GetDeletedHandler(db, logger))                    // GET /admin/deleted/ads - удалённые записи (ads, users, responses, categories, price-units)
+	admin.Patch("/deleted/{entity}/{id}/restore", RestoreDeletedHandler(db, logger)) // PATCH /admin/deleted/ads/123/restore - восстановить запись
+
+	// Журнал аудита
+	admin.Get("/audit", GetAuditLogHandler(db, logger)) // GET /admin/audit - журнал действий администраторов
+
 	// Статистика
-	admin.Get("/stats", GetStatsHandler(db, logger)) // GET /admin/stats - общая статистика
+	admin.Get("/stats", GetStatsHandler(db, logger))              // GET /admin/stats - общая статистика
+	admin.Get("/stats/series", GetStatsSeriesHandler(db, logger)) // GET /admin/stats/series - динамика по дням/неделям/месяцам
 
 	// Черный список
 	admin.Get("/blacklist", GetBlacklistHandler(db, logger))                   // GET /admin/blacklist
@@ -47,13 +74,20 @@ func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
 	// Управление справочниками
 	// Категории
 	admin.Post("/categories", CreateCategoryHandler(db, logger))                // POST /admin/categories - создать категорию
+	admin.Post("/categories/import", ImportCategoriesHandler(db, logger))       // POST /admin/categories/import - импорт из CSV (?dry_run=true)
+	admin.Post("/categories/merge", MergeCategoriesHandler(db, logger))         // POST /admin/categories/merge - слить дубликаты в одну категорию
 	admin.Patch("/categories/{categoryID}", UpdateCategoryHandler(db, logger))  // PATCH /admin/categories/123 - обновить категорию
 	admin.Delete("/categories/{categoryID}", DeleteCategoryHandler(db, logger)) // DELETE /admin/categories/123 - удалить категорию
 
 	// Единицы цены
 	admin.Post("/price-units", CreatePriceUnitHandler(db, logger))                 // POST /admin/price-units - создать единицу цены
+	admin.Post("/price-units/import", ImportPriceUnitsHandler(db, logger))         // POST /admin/price-units/import - импорт из CSV (?dry_run=true)
+	admin.Post("/price-units/merge", MergePriceUnitsHandler(db, logger))           // POST /admin/price-units/merge - слить дубликаты в одну единицу цены
 	admin.Patch("/price-units/{priceUnitID}", UpdatePriceUnitHandler(db, logger))  // PATCH /admin/price-units/123 - обновить единицу цены
 	admin.Delete("/price-units/{priceUnitID}", DeletePriceUnitHandler(db, logger)) // DELETE /admin/price-units/123 - удалить единицу цены
 
+	// История слияний справочников
+	admin.Get("/merges", GetMergesHandler(db, logger)) // GET /admin/merges - история слияний (?entity=category|price_unit)
+
 	r.Mount("/admin", admin)
 }
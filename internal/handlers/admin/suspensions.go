@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"encoding/json"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ======================================================================
+// ОГРАНИЧЕНИЯ АККАУНТОВ
+// ======================================================================
+
+var suspensionScopes = map[string]bool{
+	storage.SuspensionScopeAds:       true,
+	storage.SuspensionScopeResponses: true,
+	storage.SuspensionScopeLogin:     true,
+}
+
+// SuspendUserHandler - ограничить аккаунт пользователя
+func SuspendUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		adminID, _ := r.Context().Value("user_id").(uint)
+
+		userIDStr := chi.URLParam(r, "userID")
+		userID, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid user id"}`, http.StatusBadRequest)
+			return
+		}
+
+		type SuspendRequest struct {
+			Scope    string     `json:"scope"`
+			Reason   string     `json:"reason"`
+			StartsAt *time.Time `json:"starts_at"` // по умолчанию - сейчас
+			EndsAt   *time.Time `json:"ends_at"`   // или duration
+			Duration string     `json:"duration"`  // например "72h"; без ends_at и duration - бессрочно
+		}
+
+		var req SuspendRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		if !suspensionScopes[req.Scope] {
+			http.Error(w, `{"error": "scope must be one of: ads, responses, login"}`, http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			http.Error(w, `{"error": "reason is required"}`, http.StatusBadRequest)
+			return
+		}
+		if uint(userID) == adminID {
+			http.Error(w, `{"error": "cannot suspend yourself"}`, http.StatusBadRequest)
+			return
+		}
+
+		startsAt := time.Now()
+		if req.StartsAt != nil {
+			startsAt = *req.StartsAt
+		}
+
+		endsAt := req.EndsAt
+		if endsAt == nil && req.Duration != "" {
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				http.Error(w, `{"error": "invalid duration"}`, http.StatusBadRequest)
+				return
+			}
+			end := startsAt.Add(d)
+			endsAt = &end
+		}
+		if endsAt != nil && !endsAt.After(startsAt) {
+			http.Error(w, `{"error": "ends_at must be after starts_at"}`, http.StatusBadRequest)
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			http.Error(w, `{"error": "user not found"}`, http.StatusNotFound)
+			return
+		}
+
+		suspension := models.Suspension{
+			UserID:    user.ID,
+			Scope:     req.Scope,
+			Reason:    req.Reason,
+			StartsAt:  startsAt,
+			EndsAt:    endsAt,
+			CreatedBy: adminID,
+		}
+		if err := db.Create(&suspension).Error; err != nil {
+			logger.Error("failed to suspend user", "error", err)
+			http.Error(w, `{"error": "failed to suspend user"}`, http.StatusInternalServerError)
+			return
+		}
+
+		recordAudit(db, logger, r, "user.suspend", "suspension", suspension.ID, nil, suspension)
+
+		logger.Info("user suspended by admin", "user_id", userID, "admin_id", adminID, "scope", req.Scope, "ends_at", endsAt)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(suspension)
+	}
+}
+
+// GetSuspensionsHandler - список ограничений (?user_id=&scope=&active=true)
+func GetSuspensionsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		query := db.Model(&models.Suspension{})
+
+		// Фильтры
+		if userID := r.URL.Query().Get("user_id"); userID != "" {
+			query = query.Where("user_id = ?", userID)
+		}
+		if scope := r.URL.Query().Get("scope"); scope != "" {
+			query = query.Where("scope = ?", scope)
+		}
+		if r.URL.Query().Get("active") == "true" {
+			now := time.Now()
+			query = query.Where("lifted_at IS NULL AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var suspensions []models.Suspension
+		if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&suspensions).Error; err != nil {
+			logger.Error("failed to get suspensions", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"suspensions": suspensions,
+			"total":       total,
+			"limit":       limit,
+			"offset":      offset,
+		})
+	}
+}
+
+// LiftSuspensionHandler - досрочно снять ограничение
+func LiftSuspensionHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		adminID, _ := r.Context().Value("user_id").(uint)
+
+		suspensionIDStr := chi.URLParam(r, "suspensionID")
+		suspensionID, err := strconv.ParseUint(suspensionIDStr, 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid suspension id"}`, http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		result := db.Model(&models.Suspension{}).
+			Where("id = ? AND lifted_at IS NULL", suspensionID).
+			Updates(map[string]interface{}{"lifted_at": now, "lifted_by": adminID})
+		if result.Error != nil {
+			logger.Error("failed to lift suspension", "error", result.Error)
+			http.Error(w, `{"error": "failed to lift suspension"}`, http.StatusInternalServerError)
+			return
+		}
+		if result.RowsAffected == 0 {
+			http.Error(w, `{"error": "suspension not found or already lifted"}`, http.StatusNotFound)
+			return
+		}
+
+		recordAudit(db, logger, r, "suspension.lift", "suspension", suspensionID,
+			map[string]interface{}{"lifted_at": nil}, map[string]interface{}{"lifted_at": now, "lifted_by": adminID})
+
+		logger.Info("suspension lifted by admin", "suspension_id", suspensionID, "admin_id", adminID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":       "suspension lifted successfully",
+			"suspension_id": suspensionID,
+		})
+	}
+}
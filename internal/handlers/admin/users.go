@@ -3,6 +3,8 @@ package admin
 import (
 	"encoding/json"
 	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -12,9 +14,49 @@ import (
 	"gorm.io/gorm"
 )
 
+type userInfo struct {
+	ID                uint      `json:"id"`
+	Email             string    `json:"email"`
+	Name              string    `json:"name"`
+	Phone             string    `json:"phone"`
+	RoleID            uint      `json:"role_id"`
+	RoleName          string    `json:"role_name"`
+	CreatedAt         time.Time `json:"created_at"`
+	HaveWorkerProfile bool      `json:"have_worker_profile"`
+	AdsCount          int64     `json:"ads_count"`
+	ResponsesCount    int64     `json:"responses_count"`
+}
+
+// usersQuery - запрос списка пользователей с фильтрами (?role=&search=), общий для списка и экспорта
+func usersQuery(db *gorm.DB, r *http.Request) *gorm.DB {
+	query := db.Table("users u").
+		Select("u.id, u.email, u.name, u.phone, u.role_id, u.created_at, " +
+			"r.role_name, " +
+			"COALESCE(wp.have_worker_profile, false) as have_worker_profile, " +
+			"COUNT(DISTINCT a.id) as ads_count, " +
+			"COUNT(DISTINCT resp.id) as responses_count").
+		Joins("JOIN roles r ON u.role_id = r.id").
+		Joins("LEFT JOIN worker_profiles wp ON u.id = wp.user_id").
+		Joins("LEFT JOIN ads a ON u.id = a.user_id AND a.deleted_at IS NULL").
+		Joins("LEFT JOIN responses resp ON u.id = resp.worker_id AND resp.deleted_at IS NULL").
+		Where("u.deleted_at IS NULL").
+		Group("u.id, u.email, u.name, u.phone, u.role_id, u.created_at, r.role_name, wp.have_worker_profile").
+		Order("u.created_at DESC")
+
+	// Фильтры
+	if role := r.URL.Query().Get("role"); role != "" {
+		query = query.Where("r.role_name = ?", role)
+	}
+	if search := r.URL.Query().Get("search"); search != "" {
+		query = query.Where("u.email ILIKE ? OR u.name ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	return query
+}
+
 // GetUsersHandler - получить список всех пользователей
 func GetUsersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		limit := 10
@@ -26,44 +68,11 @@ func GetUsersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			offset, _ = strconv.Atoi(o)
 		}
 
-		type UserInfo struct {
-			ID                uint      `json:"id"`
-			Email             string    `json:"email"`
-			Name              string    `json:"name"`
-			Phone             string    `json:"phone"`
-			RoleID            uint      `json:"role_id"`
-			RoleName          string    `json:"role_name"`
-			CreatedAt         time.Time `json:"created_at"`
-			HaveWorkerProfile bool      `json:"have_worker_profile"`
-			AdsCount          int64     `json:"ads_count"`
-			ResponsesCount    int64     `json:"responses_count"`
-		}
-
-		var users []UserInfo
-		query := db.Table("users u").
-			Select("u.id, u.email, u.name, u.phone, u.role_id, u.created_at, " +
-				"r.role_name, " +
-				"COALESCE(wp.have_worker_profile, false) as have_worker_profile, " +
-				"COUNT(DISTINCT a.id) as ads_count, " +
-				"COUNT(DISTINCT resp.id) as responses_count").
-			Joins("JOIN roles r ON u.role_id = r.id").
-			Joins("LEFT JOIN worker_profiles wp ON u.id = wp.user_id").
-			Joins("LEFT JOIN ads a ON u.id = a.user_id AND a.deleted_at IS NULL").
-			Joins("LEFT JOIN responses resp ON u.id = resp.worker_id AND resp.deleted_at IS NULL").
-			Where("u.deleted_at IS NULL").
-			Group("u.id, u.email, u.name, u.phone, u.role_id, u.created_at, r.role_name, wp.have_worker_profile").
-			Order("u.created_at DESC").
+		var users []userInfo
+		query := usersQuery(db, r).
 			Limit(limit).
 			Offset(offset)
 
-		// Фильтры
-		if role := r.URL.Query().Get("role"); role != "" {
-			query = query.Where("r.role_name = ?", role)
-		}
-		if search := r.URL.Query().Get("search"); search != "" {
-			query = query.Where("u.email ILIKE ? OR u.name ILIKE ?", "%"+search+"%", "%"+search+"%")
-		}
-
 		var total int64
 		db.Model(&models.User{}).Count(&total)
 
@@ -85,6 +94,7 @@ func GetUsersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // GetUserHandler - получить пользователя по ID
 func GetUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userIDStr := chi.URLParam(r, "userID")
@@ -107,6 +117,12 @@ func GetUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 		var responsesCount int64
 		db.Model(&models.Response{}).Where("worker_id = ?", userID).Count(&responsesCount)
 
+		// Действующие ограничения
+		suspensions, err := storage.ActiveSuspensions(db, user.ID)
+		if err != nil {
+			logger.Error("failed to get user suspensions", "error", err)
+		}
+
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"id":              user.ID,
 			"email":           user.Email,
@@ -118,6 +134,8 @@ func GetUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			"worker_profile":  user.WorkerProfile,
 			"ads_count":       len(user.Ads),
 			"responses_count": responsesCount,
+			"suspended":       len(suspensions) > 0,
+			"suspensions":     suspensions,
 		})
 	}
 }
@@ -125,6 +143,7 @@ func GetUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // DeleteUserHandler - удалить пользователя (soft delete)
 func DeleteUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userIDStr := chi.URLParam(r, "userID")
@@ -141,13 +160,25 @@ func DeleteUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		// Мягкое удаление
-		if err := db.Delete(&user).Error; err != nil {
+		// Мягкое удаление вместе с профилем мастера (восстанавливаются тоже вместе)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&user).Error; err != nil {
+				return err
+			}
+			return tx.Where("user_id = ?", user.ID).Delete(&models.WorkerProfile{}).Error
+		})
+		if err != nil {
 			logger.Error("failed to delete user", "error", err)
 			http.Error(w, `{"error": "failed to delete user"}`, http.StatusInternalServerError)
 			return
 		}
 
+		recordAudit(db, logger, r, "user.delete", "user", user.ID, map[string]interface{}{
+			"email":   user.Email,
+			"name":    user.Name,
+			"role_id": user.RoleID,
+		}, nil)
+
 		logger.Info("user deleted by admin", "user_id", userID)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "user deleted successfully",
@@ -158,6 +189,7 @@ func DeleteUserHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // UpdateUserRoleHandler - изменить роль пользователя
 func UpdateUserRoleHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userIDStr := chi.URLParam(r, "userID")
@@ -196,6 +228,8 @@ func UpdateUserRoleHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		oldRoleID := user.RoleID
+
 		// Обновляем роль
 		if err := db.Model(&user).Update("role_id", role.ID).Error; err != nil {
 			logger.Error("failed to update user role", "error", err)
@@ -203,6 +237,9 @@ func UpdateUserRoleHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		recordAudit(db, logger, r, "user.role_update", "user", user.ID,
+			map[string]uint{"role_id": oldRoleID}, map[string]uint{"role_id": role.ID})
+
 		logger.Info("user role updated by admin", "user_id", userID, "new_role", req.RoleName)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "role updated successfully",
@@ -2,9 +2,16 @@ package ads
 
 import (
 	"encoding/json"
+	"go-api/internal/cache"
+	"go-api/internal/metrics"
+	"go-api/internal/middleware"
 	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
@@ -15,6 +22,7 @@ import (
 // PublicAdsHandler - публичный доступ (только GET)
 func PublicAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		if r.Method == http.MethodGet {
@@ -28,6 +36,7 @@ func PublicAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 // ProtectedAdsHandler - защищённый доступ (POST/PATCH/DELETE/GET для личных объявлений)
 func ProtectedAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userID, ok := r.Context().Value("user_id").(uint)
@@ -56,7 +65,7 @@ func getAdsPublic(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *ht
 
 	if adIDStr != "" {
 		id, _ := strconv.ParseUint(adIDStr, 10, 32)
-		getAdByIDPublic(db, logger, w, uint(id))
+		getAdByIDPublic(db, logger, w, r, uint(id))
 		return
 	}
 	limit := 10
@@ -88,26 +97,29 @@ func getAdsProtected(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r
 	if o := r.URL.Query().Get("offset"); o != "" {
 		offset, _ = strconv.Atoi(o)
 	}
-	getMyAdsList(db, logger, w, userID, limit, offset)
+	getMyAdsList(db, logger, w, userID, r.URL.Query().Get("status"), limit, offset)
 }
 
 // Вспомогательные GET функции
 
-// Объявление по id (публичное)
-func getAdByIDPublic(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, adID uint) {
+// Объявление по id (публичное). Поддерживает условные запросы (ETag / Last-Modified → 304)
+func getAdByIDPublic(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, adID uint) {
 	var ad models.Ad
 
 	if err := db.Preload("Category").Preload("PriceUnit").Preload("User").
-		Where("id = ? AND status = ?", adID, "approved").
+		Where("id = ? AND status = ? AND visibility = ?", adID, models.AdStatusActive, models.AdVisibilityPublic).
 		First(&ad).Error; err != nil {
 		http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
 		return
 	}
 
-	json.NewEncoder(w).Encode(ad)
+	if err := cache.WriteJSON(w, r, ad, ad.UpdatedAt, cache.ControlRevalidate); err != nil {
+		logger.Error("failed to encode ad", "error", err)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+	}
 }
 
-// Объявление по id (для владельца)
+// Объявление по id (для владельца). ETag - версия объявления, её нужно передать в If-Match при PATCH
 func getAdByID(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, adID uint, ownerID uint) {
 	var ad models.Ad
 
@@ -118,38 +130,47 @@ func getAdByID(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, adID uin
 		return
 	}
 
+	w.Header().Set("ETag", cache.VersionETag(ad.Version))
 	json.NewEncoder(w).Encode(ad)
 }
 
 // Список объявлений (публичный)
 func getAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, limit, offset int) {
 	type AdList struct {
-		ID            uint      `json:"id"`
-		Title         string    `json:"title"`
-		Price         float64   `json:"price"`
-		Location      string    `json:"location"`
-		Schedule      string    `json:"schedule"`
-		CreatedAt     time.Time `json:"created_at"`
-		CategoryID    uint      `json:"category_id"`
-		CategoryName  string    `json:"category_name"`
-		PriceUnitID   uint      `json:"price_unit_id"`
-		PriceUnitName string    `json:"price_unit_name"`
-		UserID        uint      `json:"user_id"`
-		UserName      string    `json:"user_name"`
-		UserPhone     string    `json:"user_phone"`
+		ID            uint             `json:"id"`
+		Title         string           `json:"title"`
+		Description   string           `json:"description"`
+		Price         float64          `json:"price"`
+		BudgetMin     *float64         `json:"budget_min"`
+		BudgetMax     *float64         `json:"budget_max"`
+		Negotiable    bool             `json:"negotiable"`
+		Urgency       string           `json:"urgency"`
+		Location      string           `json:"location"`
+		DateFrom      *time.Time       `json:"date_from"`
+		DateTo        *time.Time       `json:"date_to"`
+		TimeSlots     models.TimeSlots `json:"time_slots"`
+		CreatedAt     time.Time        `json:"created_at"`
+		CategoryID    uint             `json:"category_id"`
+		CategoryName  string           `json:"category_name"`
+		PriceUnitID   uint             `json:"price_unit_id"`
+		PriceUnitName string           `json:"price_unit_name"`
+		UserID        uint             `json:"user_id"`
+		UserName      string           `json:"user_name"`
+		UserPhone     string           `json:"user_phone"`
 	}
 
 	var ads []AdList
 	query := db.Table("ads a").
-		Select("a.id, a.title, a.price, a.location, a.schedule, a.created_at, "+
+		Select("a.id, a.title, a.description, a.price, a.budget_min, a.budget_max, a.negotiable, a.urgency, "+
+			"a.location, a.date_from, a.date_to, a.time_slots, a.created_at, "+
 			"c.id as category_id, c.name as category_name, "+
 			"pu.id as price_unit_id, pu.name as price_unit_name, "+
 			"u.id as user_id, u.name as user_name, u.phone as user_phone").
 		Joins("JOIN categories c ON a.category_id = c.id").
 		Joins("JOIN price_units pu ON a.price_unit_id = pu.id").
 		Joins("JOIN users u ON a.user_id = u.id").
-		Where("a.status = ?", "approved").
-		Order("a.created_at DESC").
+		Where("a.status = ? AND a.visibility = ?", models.AdStatusActive, models.AdVisibilityPublic).
+		Order("COALESCE(a.published_at, a.created_at) DESC"). // повторно опубликованные - снова наверху
 		Limit(limit).
 		Offset(offset)
 
@@ -157,12 +178,86 @@ func getAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http
 	if category := r.URL.Query().Get("category"); category != "" {
 		query = query.Where("c.name ILIKE ?", "%"+category+"%")
 	}
+	// category_id / category_slug - категория вместе со всеми подкатегориями
+	ref := r.URL.Query().Get("category_id")
+	if ref == "" {
+		ref = r.URL.Query().Get("category_slug")
+	}
+	if ref != "" {
+		category, err := storage.CategoryByRef(db, ref)
+		if err != nil {
+			http.Error(w, `{"error": "category not found"}`, http.StatusNotFound)
+			return
+		}
+		ids, err := storage.CategoryDescendantIDs(db, category.ID)
+		if err != nil {
+			logger.Error("failed to get category subtree", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		query = query.Where("a.category_id IN ?", ids)
+	}
 	if location := r.URL.Query().Get("location"); location != "" {
 		query = query.Where("a.location ILIKE ?", "%"+location+"%")
 	}
+	if q := r.URL.Query().Get("q"); q != "" {
+		query = query.Where("(a.title ILIKE ? OR a.description ILIKE ?)", "%"+q+"%", "%"+q+"%")
+	}
+	// budget_from / budget_to - бюджет объявления пересекается с диапазоном (объявления без бюджета не подходят)
+	if v := r.URL.Query().Get("budget_from"); v != "" {
+		from, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, `{"error": "invalid budget_from"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("COALESCE(a.budget_max, a.budget_min) >= ?", from)
+	}
+	if v := r.URL.Query().Get("budget_to"); v != "" {
+		to, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, `{"error": "invalid budget_to"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("COALESCE(a.budget_min, a.budget_max) <= ?", to)
+	}
+	if r.URL.Query().Get("negotiable") == "true" {
+		query = query.Where("a.negotiable")
+	}
+	if urgency := r.URL.Query().Get("urgency"); urgency != "" {
+		if urgency != models.AdUrgencyFlexible && urgency != models.AdUrgencyNormal && urgency != models.AdUrgencyUrgent {
+			http.Error(w, `{"error": "urgency must be flexible, normal or urgent"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("a.urgency = ?", urgency)
+	}
+	// date_from / date_to - желаемые сроки объявления пересекаются с периодом (без границы - подходят)
+	if v := r.URL.Query().Get("date_from"); v != "" {
+		from, ok := parseAdDate(v)
+		if !ok {
+			http.Error(w, `{"error": "date_from must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("(a.date_to IS NULL OR a.date_to >= ?)", *from)
+	}
+	if v := r.URL.Query().Get("date_to"); v != "" {
+		to, ok := parseAdDate(v)
+		if !ok {
+			http.Error(w, `{"error": "date_to must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("(a.date_from IS NULL OR a.date_from <= ?)", *to)
+	}
+	// time_slot - подходит это время дня (объявления без слотов - в любое время)
+	if slot := r.URL.Query().Get("time_slot"); slot != "" {
+		if !slices.Contains(models.TimeSlotNames, slot) {
+			http.Error(w, `{"error": "time_slot must be morning, afternoon or evening"}`, http.StatusBadRequest)
+			return
+		}
+		query = query.Where("(a.time_slots = '' OR ',' || a.time_slots || ',' LIKE ?)", "%,"+slot+",%")
+	}
 
 	var total int64
-	db.Model(&models.Ad{}).Where("status = ?", "approved").Count(&total)
+	db.Model(&models.Ad{}).Where("status = ? AND visibility = ?", models.AdStatusActive, models.AdVisibilityPublic).Count(&total)
 
 	if err := query.Scan(&ads).Error; err != nil {
 		logger.Error("failed to get ads list", "error", err)
@@ -185,28 +280,40 @@ func getAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http
 	})
 }
 
-// Список личных объявлений пользователя
-func getMyAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, userID uint, limit, offset int) {
+// Список личных объявлений пользователя (status - фильтр по статусу, пусто - все)
+func getMyAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, userID uint, status string, limit, offset int) {
 	type AdList struct {
-		ID            uint      `json:"id"`
-		Title         string    `json:"title"`
-		Price         float64   `json:"price"`
-		Location      string    `json:"location"`
-		Schedule      string    `json:"schedule"`
-		CreatedAt     time.Time `json:"created_at"`
-		CategoryID    uint      `json:"category_id"`
-		CategoryName  string    `json:"category_name"`
-		PriceUnitID   uint      `json:"price_unit_id"`
-		PriceUnitName string    `json:"price_unit_name"`
-		Status        string    `json:"status"` // владелец видит статус модерации
+		ID            uint             `json:"id"`
+		Title         string           `json:"title"`
+		Description   string           `json:"description"`
+		Price         float64          `json:"price"`
+		BudgetMin     *float64         `json:"budget_min"`
+		BudgetMax     *float64         `json:"budget_max"`
+		Negotiable    bool             `json:"negotiable"`
+		Urgency       string           `json:"urgency"`
+		Location      string           `json:"location"`
+		DateFrom      *time.Time       `json:"date_from"`
+		DateTo        *time.Time       `json:"date_to"`
+		TimeSlots     models.TimeSlots `json:"time_slots"`
+		CreatedAt     time.Time        `json:"created_at"`
+		CategoryID    uint             `json:"category_id"`
+		CategoryName  string           `json:"category_name"`
+		PriceUnitID   uint             `json:"price_unit_id"`
+		PriceUnitName string           `json:"price_unit_name"`
+		Visibility    string           `json:"visibility"`
+		Status        string           `json:"status"`  // владелец видит статус модерации и жизненного цикла
+		Version       uint             `json:"version"` // для If-Match при изменении
+		ExpiresAt     *time.Time       `json:"expires_at"`
+		ClosedReason  string           `json:"closed_reason,omitempty"`
 	}
 
 	var ads []AdList
 	query := db.Table("ads a").
-		Select("a.id, a.title, a.price, a.location, a.schedule, a.created_at, "+
+		Select("a.id, a.title, a.description, a.price, a.budget_min, a.budget_max, a.negotiable, a.urgency, "+
+			"a.location, a.date_from, a.date_to, a.time_slots, a.created_at, "+
 			"c.id as category_id, c.name as category_name, "+
 			"pu.id as price_unit_id, pu.name as price_unit_name, "+
-			"a.status").
+			"a.visibility, a.status, a.version, a.expires_at, a.closed_reason").
 		Joins("JOIN categories c ON a.category_id = c.id").
 		Joins("JOIN price_units pu ON a.price_unit_id = pu.id").
 		Where("a.user_id = ?", userID).
@@ -214,8 +321,14 @@ func getMyAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, userI
 		Limit(limit).
 		Offset(offset)
 
+	totalQuery := db.Model(&models.Ad{}).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("a.status = ?", status)
+		totalQuery = totalQuery.Where("status = ?", status)
+	}
+
 	var total int64
-	db.Model(&models.Ad{}).Where("user_id = ?", userID).Count(&total)
+	totalQuery.Count(&total)
 
 	if err := query.Scan(&ads).Error; err != nil {
 		logger.Error("failed to get my ads list", "error", err)
@@ -240,12 +353,21 @@ func getMyAdsList(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, userI
 
 func createAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, userID uint) {
 	type CreateAdRequest struct {
-		Title       string  `json:"title"`
-		Price       float64 `json:"price"`
-		CategoryID  uint    `json:"category_id"`
-		PriceUnitID uint    `json:"price_unit_id"`
-		Location    string  `json:"location"`
-		Schedule    string  `json:"schedule"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Price       float64  `json:"price"` // фиксированная цена: то же, что budget_min = budget_max
+		BudgetMin   *float64 `json:"budget_min"`
+		BudgetMax   *float64 `json:"budget_max"`
+		Negotiable  bool     `json:"negotiable"`
+		Urgency     string   `json:"urgency"`
+		CategoryID  uint     `json:"category_id"`
+		PriceUnitID uint     `json:"price_unit_id"`
+		Location    string   `json:"location"`
+		Visibility  string   `json:"visibility"` // public (по умолчанию) или private - только по приглашениям
+		DateFrom    string   `json:"date_from"`  // YYYY-MM-DD
+		DateTo      string   `json:"date_to"`
+		TimeSlots   []string `json:"time_slots"`
+		Draft       bool     `json:"draft"` // сохранить черновиком, без отправки на модерацию
 	}
 
 	var req CreateAdRequest
@@ -255,12 +377,26 @@ func createAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if !checkNotSuspended(db, logger, w, userID, storage.SuspensionScopeAds) {
+		return
+	}
+
 	// Валидация
-	if req.Title == "" || req.Price <= 0 || req.CategoryID == 0 || req.PriceUnitID == 0 {
-		http.Error(w, `{"error": "title, price, category_id and price_unit_id are required"}`, http.StatusBadRequest)
+	if req.Title == "" || req.CategoryID == 0 || req.PriceUnitID == 0 {
+		http.Error(w, `{"error": "title, category_id and price_unit_id are required"}`, http.StatusBadRequest)
+		return
+	}
+	dateFrom, okFrom := parseAdDate(req.DateFrom)
+	dateTo, okTo := parseAdDate(req.DateTo)
+	if !okFrom || !okTo {
+		http.Error(w, `{"error": "date_from and date_to must be YYYY-MM-DD"}`, http.StatusBadRequest)
 		return
 	}
 
+	// Старые ID влитых справочников ведут на актуальные
+	req.CategoryID = storage.ResolveRedirect(db, storage.RedirectCategory, req.CategoryID)
+	req.PriceUnitID = storage.ResolveRedirect(db, storage.RedirectPriceUnit, req.PriceUnitID)
+
 	// Проверяем существование категории и единицы измерения
 	var category models.Category
 	if err := db.First(&category, req.CategoryID).Error; err != nil {
@@ -276,13 +412,41 @@ func createAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 
 	ad := models.Ad{
 		Title:       req.Title,
-		Price:       req.Price,
+		Description: req.Description,
+		BudgetMin:   budgetBound(req.BudgetMin),
+		BudgetMax:   budgetBound(req.BudgetMax),
+		Negotiable:  req.Negotiable,
+		Urgency:     req.Urgency,
 		CategoryID:  req.CategoryID,
 		PriceUnitID: req.PriceUnitID,
 		UserID:      userID,
 		Location:    req.Location,
-		Schedule:    req.Schedule,
+		Visibility:  req.Visibility,
+		DateFrom:    dateFrom,
+		DateTo:      dateTo,
+		TimeSlots:   req.TimeSlots,
 		CreatedAt:   time.Now(),
+		Status:      models.AdStatusDraft,
+	}
+	// Единственная цена - фиксированный бюджет
+	if req.Price != 0 && ad.BudgetMin == nil && ad.BudgetMax == nil {
+		ad.BudgetMin, ad.BudgetMax = &req.Price, &req.Price
+	}
+	if msg := validateAdContent(&ad, true); msg != "" {
+		http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Автомодерация (черновик проверяется при отправке, см. AdLifecycleHandler)
+	var verdict *moderation.Result
+	if !req.Draft {
+		res := moderation.Evaluate(db, adSubject(ad))
+		verdict = &res
+		ad.Status = res.AdStatus()
+		if ad.Status == models.AdStatusActive {
+			expiresAt := storage.AdExpiry(ad.CreatedAt)
+			ad.PublishedAt, ad.ExpiresAt = &ad.CreatedAt, &expiresAt
+		}
 	}
 
 	if err := db.Create(&ad).Error; err != nil {
@@ -290,10 +454,18 @@ func createAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		http.Error(w, `{"error": "failed to create ad"}`, http.StatusInternalServerError)
 		return
 	}
+	metrics.AdsCreated.Inc()
+
+	if verdict != nil {
+		if err := moderation.Record(db, moderation.EntityAd, ad.ID, *verdict); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "ad_id", ad.ID)
+		}
+	}
 
 	// Загружаем связанные данные для ответа
 	db.Preload("Category").Preload("PriceUnit").Preload("User").First(&ad, ad.ID)
 
+	w.Header().Set("ETag", cache.VersionETag(ad.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(ad)
 }
@@ -311,13 +483,27 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// Оптимистичная блокировка: изменение только той версии, которую клиент видел
+	version, anyVersion, ok := cache.RequireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	type UpdateAdRequest struct {
-		Title       *string  `json:"title"`
-		Price       *float64 `json:"price"`
-		CategoryID  *uint    `json:"category_id"`
-		PriceUnitID *uint    `json:"price_unit_id"`
-		Location    *string  `json:"location"`
-		Schedule    *string  `json:"schedule"`
+		Title       *string   `json:"title"`
+		Description *string   `json:"description"`
+		Price       *float64  `json:"price"`      // фиксированная цена: budget_min = budget_max
+		BudgetMin   *float64  `json:"budget_min"` // 0 - убрать границу
+		BudgetMax   *float64  `json:"budget_max"`
+		Negotiable  *bool     `json:"negotiable"`
+		Urgency     *string   `json:"urgency"`
+		CategoryID  *uint     `json:"category_id"`
+		PriceUnitID *uint     `json:"price_unit_id"`
+		Location    *string   `json:"location"`
+		Visibility  *string   `json:"visibility"`
+		DateFrom    *string   `json:"date_from"` // "" - убрать границу
+		DateTo      *string   `json:"date_to"`
+		TimeSlots   *[]string `json:"time_slots"`
 	}
 
 	var req UpdateAdRequest
@@ -327,6 +513,10 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if !checkNotSuspended(db, logger, w, userID, storage.SuspensionScopeAds) {
+		return
+	}
+
 	// Находим объявление и проверяем владельца
 	var ad models.Ad
 	if err := db.Where("id = ? AND user_id = ?", uint(adID), userID).First(&ad).Error; err != nil {
@@ -338,6 +528,16 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		}
 		return
 	}
+	if !anyVersion && ad.Version != version {
+		cache.PreconditionFailed(w, ad.Version)
+		return
+	}
+
+	// Закрытое объявление не меняется, истёкшее - только после повторной публикации
+	if ad.Status == models.AdStatusClosed || ad.Status == models.AdStatusExpired {
+		http.Error(w, `{"error": "`+ad.Status+` ad cannot be edited", "status": "`+ad.Status+`"}`, http.StatusConflict)
+		return
+	}
 
 	// Обновляем только переданные поля
 	updates := make(map[string]interface{})
@@ -348,14 +548,11 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		}
 		updates["title"] = *req.Title
 	}
-	if req.Price != nil {
-		if *req.Price <= 0 {
-			http.Error(w, `{"error": "price must be greater than 0"}`, http.StatusBadRequest)
-			return
-		}
-		updates["price"] = *req.Price
+	if req.Description != nil {
+		updates["description"] = *req.Description
 	}
 	if req.CategoryID != nil {
+		*req.CategoryID = storage.ResolveRedirect(db, storage.RedirectCategory, *req.CategoryID)
 		var category models.Category
 		if err := db.First(&category, *req.CategoryID).Error; err != nil {
 			http.Error(w, `{"error": "category not found"}`, http.StatusNotFound)
@@ -364,6 +561,7 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		updates["category_id"] = *req.CategoryID
 	}
 	if req.PriceUnitID != nil {
+		*req.PriceUnitID = storage.ResolveRedirect(db, storage.RedirectPriceUnit, *req.PriceUnitID)
 		var priceUnit models.PriceUnit
 		if err := db.First(&priceUnit, *req.PriceUnitID).Error; err != nil {
 			http.Error(w, `{"error": "price unit not found"}`, http.StatusNotFound)
@@ -374,8 +572,70 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 	if req.Location != nil {
 		updates["location"] = *req.Location
 	}
-	if req.Schedule != nil {
-		updates["schedule"] = *req.Schedule
+
+	// Бюджет, срочность и сроки проверяются вместе, с учётом незатронутых полей объявления
+	content := ad
+	budgetChanged := req.Price != nil || req.BudgetMin != nil || req.BudgetMax != nil || req.Negotiable != nil
+	datesChanged := req.DateFrom != nil || req.DateTo != nil
+	if req.Description != nil {
+		content.Description = *req.Description
+	}
+	if req.Price != nil {
+		content.BudgetMin, content.BudgetMax = budgetBound(req.Price), budgetBound(req.Price)
+	}
+	if req.BudgetMin != nil {
+		content.BudgetMin = budgetBound(req.BudgetMin)
+	}
+	if req.BudgetMax != nil {
+		content.BudgetMax = budgetBound(req.BudgetMax)
+	}
+	if req.Negotiable != nil {
+		content.Negotiable = *req.Negotiable
+	}
+	if req.Urgency != nil {
+		content.Urgency = *req.Urgency
+	}
+	if req.Visibility != nil {
+		content.Visibility = *req.Visibility
+	}
+	if datesChanged {
+		ok := true
+		if req.DateFrom != nil {
+			content.DateFrom, ok = parseAdDate(*req.DateFrom)
+		}
+		if ok && req.DateTo != nil {
+			content.DateTo, ok = parseAdDate(*req.DateTo)
+		}
+		if !ok {
+			http.Error(w, `{"error": "date_from and date_to must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.TimeSlots != nil {
+		content.TimeSlots = *req.TimeSlots
+	}
+	if msg := validateAdContent(&content, datesChanged); msg != "" {
+		http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	if budgetChanged {
+		updates["budget_min"] = content.BudgetMin
+		updates["budget_max"] = content.BudgetMax
+		updates["negotiable"] = content.Negotiable
+		updates["price"] = content.Price
+	}
+	if req.Urgency != nil {
+		updates["urgency"] = content.Urgency
+	}
+	if req.Visibility != nil {
+		updates["visibility"] = content.Visibility
+	}
+	if datesChanged {
+		updates["date_from"] = content.DateFrom
+		updates["date_to"] = content.DateTo
+	}
+	if req.TimeSlots != nil {
+		updates["time_slots"] = content.TimeSlots
 	}
 
 	if len(updates) == 0 {
@@ -383,15 +643,60 @@ func updateAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if err := db.Model(&ad).Updates(updates).Error; err != nil {
-		logger.Error("failed to update ad", "error", err)
+	// Изменение содержимого объявления - повторная автомодерация (черновик проверяется при отправке)
+	var verdict *moderation.Result
+	if ad.Status != models.AdStatusDraft && (req.Title != nil || req.Description != nil || budgetChanged || req.CategoryID != nil || req.PriceUnitID != nil) {
+		if req.Title != nil {
+			content.Title = *req.Title
+		}
+		if req.CategoryID != nil {
+			content.CategoryID = *req.CategoryID
+		}
+		if req.PriceUnitID != nil {
+			content.PriceUnitID = *req.PriceUnitID
+		}
+		res := moderation.Evaluate(db, adSubject(content))
+		verdict = &res
+		status := res.AdStatus()
+		switch {
+		case status == models.AdStatusActive && ad.Status == models.AdStatusPaused:
+			status = models.AdStatusPaused // скрытое владельцем остаётся скрытым
+		case status == models.AdStatusActive && ad.Status != models.AdStatusActive:
+			for k, v := range storage.AdPublication(time.Now()) {
+				updates[k] = v
+			}
+		}
+		updates["status"] = status
+	}
+
+	// Условие на версию отсекает изменение (например, модерацию), случившееся после чтения
+	updates["version"] = storage.NextVersion()
+	result := db.Model(&models.Ad{}).Where("id = ? AND version = ?", ad.ID, ad.Version).Updates(updates)
+	if result.Error != nil {
+		logger.Error("failed to update ad", "error", result.Error)
 		http.Error(w, `{"error": "failed to update ad"}`, http.StatusInternalServerError)
 		return
 	}
+	if result.RowsAffected == 0 {
+		var current models.Ad
+		if err := db.Select("id, version").First(&current, ad.ID).Error; err != nil {
+			http.Error(w, `{"error": "ad not found or access denied"}`, http.StatusNotFound)
+			return
+		}
+		cache.PreconditionFailed(w, current.Version)
+		return
+	}
+
+	if verdict != nil {
+		if err := moderation.Record(db, moderation.EntityAd, ad.ID, *verdict); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "ad_id", ad.ID)
+		}
+	}
 
 	// Загружаем обновленное объявление со связанными данными
 	db.Preload("Category").Preload("PriceUnit").Preload("User").First(&ad, ad.ID)
 
+	w.Header().Set("ETag", cache.VersionETag(ad.Version))
 	json.NewEncoder(w).Encode(ad)
 }
 
@@ -420,8 +725,8 @@ func deleteAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Мягкое удаление (GORM автоматически использует soft delete)
-	if err := db.Delete(&ad).Error; err != nil {
+	// Мягкое удаление (GORM автоматически использует soft delete); брони мастеров освобождаются, торг закрывается
+	if err := storage.DeleteAd(db, &ad); err != nil {
 		logger.Error("failed to delete ad", "error", err)
 		http.Error(w, `{"error": "failed to delete ad"}`, http.StatusInternalServerError)
 		return
@@ -430,3 +735,18 @@ func deleteAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "ad deleted successfully"})
 }
+
+// checkNotSuspended - false и ответ 403, если у пользователя действует ограничение
+func checkNotSuspended(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, userID uint, scope string) bool {
+	suspension, err := storage.ActiveSuspension(db, userID, scope)
+	if err != nil {
+		logger.Error("failed to check suspension", "error", err, "user_id", userID)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		return false
+	}
+	if suspension != nil {
+		middleware.SuspendedError(w, suspension)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package ads
+
+import (
+	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Содержание объявления: описание, бюджет, срочность и желаемые сроки
+
+const maxDescriptionLength = 5000
+
+const dateLayout = "2006-01-02" // формат date_from / date_to
+
+// parseAdDate - дата YYYY-MM-DD; пустая строка - без границы
+func parseAdDate(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+// budgetBound - граница бюджета из запроса: 0 означает «без границы»
+func budgetBound(v *float64) *float64 {
+	if v == nil || *v == 0 {
+		return nil
+	}
+	return v
+}
+
+// validateAdContent проверяет описание, бюджет, видимость, срочность и сроки объявления и приводит их к
+// каноническому виду (значения по умолчанию, слоты без повторов, ориентир Price по бюджету).
+// newDates - даты заданы в этом запросе: тогда окончание не может быть в прошлом.
+// Возвращает текст ошибки для ответа 400 или пустую строку
+func validateAdContent(ad *models.Ad, newDates bool) string {
+	if utf8.RuneCountInString(ad.Description) > maxDescriptionLength {
+		return "description must be at most 5000 characters"
+	}
+
+	if (ad.BudgetMin != nil && *ad.BudgetMin < 0) || (ad.BudgetMax != nil && *ad.BudgetMax < 0) {
+		return "budget must be greater than 0"
+	}
+	if ad.BudgetMin == nil && ad.BudgetMax == nil && !ad.Negotiable {
+		return "budget_min or budget_max is required unless negotiable"
+	}
+	if ad.BudgetMin != nil && ad.BudgetMax != nil && *ad.BudgetMin > *ad.BudgetMax {
+		return "budget_min must not exceed budget_max"
+	}
+
+	if ad.Visibility == "" {
+		ad.Visibility = models.AdVisibilityPublic
+	}
+	if ad.Visibility != models.AdVisibilityPublic && ad.Visibility != models.AdVisibilityPrivate {
+		return "visibility must be public or private"
+	}
+
+	if ad.Urgency == "" {
+		ad.Urgency = models.AdUrgencyNormal
+	}
+	if ad.Urgency != models.AdUrgencyFlexible && ad.Urgency != models.AdUrgencyNormal && ad.Urgency != models.AdUrgencyUrgent {
+		return "urgency must be flexible, normal or urgent"
+	}
+
+	if ad.DateFrom != nil && ad.DateTo != nil && ad.DateTo.Before(*ad.DateFrom) {
+		return "date_to must not be before date_from"
+	}
+	if newDates && ad.DateTo != nil {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if ad.DateTo.Before(today) {
+			return "date_to must not be in the past"
+		}
+	}
+
+	slots := models.TimeSlots{}
+	for _, slot := range ad.TimeSlots {
+		if !slices.Contains(models.TimeSlotNames, slot) {
+			return "time_slots must contain only morning, afternoon or evening"
+		}
+		if !slices.Contains(slots, slot) {
+			slots = append(slots, slot)
+		}
+	}
+	ad.TimeSlots = slots
+
+	ad.Price = 0
+	switch {
+	case ad.BudgetMax != nil:
+		ad.Price = *ad.BudgetMax
+	case ad.BudgetMin != nil:
+		ad.Price = *ad.BudgetMin
+	}
+	return ""
+}
+
+// adSubject - объявление для автомодерации: описание проверяется вместе с заголовком,
+// цена - только если бюджет указан
+func adSubject(ad models.Ad) moderation.Subject {
+	subject := moderation.Subject{
+		EntityType:  moderation.EntityAd,
+		UserID:      ad.UserID,
+		Title:       ad.Title,
+		Text:        ad.Description,
+		CategoryID:  ad.CategoryID,
+		PriceUnitID: ad.PriceUnitID,
+	}
+	if ad.Price > 0 {
+		price := ad.Price
+		subject.Price = &price
+	}
+	return subject
+}
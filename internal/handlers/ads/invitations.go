@@ -0,0 +1,522 @@
+package ads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/internal/metrics"
+	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Приглашения: владелец объявления зовёт конкретных мастеров откликнуться. Принятое приглашение
+// создаёт отклик и для закрытого (private) или скрытого на время объявления
+
+const (
+	maxInvitesPerRequest    = 20
+	maxInvitationMessageLen = 500
+)
+
+var errInvitationNotPending = errors.New("invitation is not pending")
+
+// invitable - объявление может получать приглашения и отклики по ним
+func invitable(ad *models.Ad) bool {
+	return ad.Status == models.AdStatusActive || ad.Status == models.AdStatusPaused
+}
+
+// InviteWorkersHandler - пригласить одобренных мастеров (worker_ids) откликнуться на своё объявление.
+// Мастера, которых пригласить нельзя, возвращаются в skipped с причиной
+func InviteWorkersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+		if !checkNotSuspended(db, logger, w, ad.UserID, storage.SuspensionScopeAds) {
+			return
+		}
+
+		var req struct {
+			WorkerIDs []uint `json:"worker_ids"`
+			Message   string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if len(req.WorkerIDs) == 0 || len(req.WorkerIDs) > maxInvitesPerRequest {
+			http.Error(w, `{"error": "worker_ids must contain 1 to 20 ids"}`, http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(req.Message) > maxInvitationMessageLen {
+			http.Error(w, `{"error": "message must be at most 500 characters"}`, http.StatusBadRequest)
+			return
+		}
+		if !invitable(ad) {
+			http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+			return
+		}
+
+		type Skipped struct {
+			WorkerID uint   `json:"worker_id"`
+			Reason   string `json:"reason"`
+		}
+		invited := []models.Invitation{}
+		skipped := []Skipped{}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var approved, responded []uint
+			if err := tx.Model(&models.WorkerProfile{}).
+				Where("user_id IN ? AND have_worker_profile = ? AND status = ?", req.WorkerIDs, true, "approved").
+				Pluck("user_id", &approved).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Response{}).
+				Where("ad_id = ? AND worker_id IN ?", ad.ID, req.WorkerIDs).
+				Pluck("worker_id", &responded).Error; err != nil {
+				return err
+			}
+			var existing []models.Invitation
+			if err := tx.Where("ad_id = ? AND worker_id IN ?", ad.ID, req.WorkerIDs).Find(&existing).Error; err != nil {
+				return err
+			}
+			isApproved, hasResponded := map[uint]bool{}, map[uint]bool{}
+			for _, id := range approved {
+				isApproved[id] = true
+			}
+			for _, id := range responded {
+				hasResponded[id] = true
+			}
+			previous := map[uint]models.Invitation{}
+			for _, inv := range existing {
+				previous[inv.WorkerID] = inv
+			}
+
+			message := fmt.Sprintf("Вас пригласили откликнуться на объявление «%s»", ad.Title)
+			seen := map[uint]bool{}
+			for _, workerID := range req.WorkerIDs {
+				if seen[workerID] {
+					continue
+				}
+				seen[workerID] = true
+
+				prev, wasInvited := previous[workerID]
+				switch {
+				case workerID == ad.UserID:
+					skipped = append(skipped, Skipped{workerID, "cannot invite yourself"})
+					continue
+				case !isApproved[workerID]:
+					skipped = append(skipped, Skipped{workerID, "worker not found"})
+					continue
+				case hasResponded[workerID]:
+					skipped = append(skipped, Skipped{workerID, "worker already responded"})
+					continue
+				case wasInvited && prev.Status != models.InvitationRevoked:
+					skipped = append(skipped, Skipped{workerID, "worker already invited"})
+					continue
+				}
+
+				// отозванное приглашение можно отправить снова
+				invitation := models.Invitation{
+					AdID:     ad.ID,
+					ClientID: ad.UserID,
+					WorkerID: workerID,
+					Message:  req.Message,
+					Status:   models.InvitationPending,
+				}
+				if wasInvited {
+					invitation.ID, invitation.CreatedAt = prev.ID, prev.CreatedAt
+				}
+				if err := tx.Save(&invitation).Error; err != nil {
+					return err
+				}
+				if err := storage.Notify(tx, workerID, storage.NotifyInvitationReceived, message, &ad.ID); err != nil {
+					return err
+				}
+				invited = append(invited, invitation)
+			}
+			return nil
+		})
+		if err != nil {
+			if storage.IsUniqueViolation(err) {
+				http.Error(w, `{"error": "worker already invited"}`, http.StatusConflict)
+				return
+			}
+			logger.Error("failed to invite workers", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "failed to invite workers"}`, http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("workers invited", "ad_id", ad.ID, "invited", len(invited), "skipped", len(skipped))
+
+		if len(invited) > 0 {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"invitations": invited,
+			"skipped":     skipped,
+		})
+	}
+}
+
+// AdInvitationsHandler - приглашения по своему объявлению (?status=) с откликами по принятым
+func AdInvitationsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+
+		type InvitationList struct {
+			ID         uint      `json:"id"`
+			WorkerID   uint      `json:"worker_id"`
+			WorkerName string    `json:"worker_name"`
+			Message    string    `json:"message"`
+			Status     string    `json:"status"`
+			ResponseID *uint     `json:"response_id"`
+			CreatedAt  time.Time `json:"created_at"`
+			UpdatedAt  time.Time `json:"updated_at"`
+		}
+
+		var invitations []InvitationList
+		query := db.Table("invitations i").
+			Select("i.id, i.worker_id, u.name as worker_name, i.message, i.status, r.id as response_id, i.created_at, i.updated_at").
+			Joins("JOIN users u ON u.id = i.worker_id").
+			Joins("LEFT JOIN responses r ON r.invitation_id = i.id AND r.deleted_at IS NULL").
+			Where("i.ad_id = ?", ad.ID).
+			Order("i.created_at DESC")
+		if status := r.URL.Query().Get("status"); status != "" {
+			query = query.Where("i.status = ?", status)
+		}
+		if err := query.Scan(&invitations).Error; err != nil {
+			logger.Error("failed to get ad invitations", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"invitations": invitations,
+			"total":       len(invitations),
+		})
+	}
+}
+
+// RevokeInvitationHandler - отозвать приглашение, на которое мастер ещё не ответил
+func RevokeInvitationHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+		invitationID, err := strconv.ParseUint(chi.URLParam(r, "invitationID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid invitation id"}`, http.StatusBadRequest)
+			return
+		}
+
+		var invitation models.Invitation
+		if err := db.Where("id = ? AND ad_id = ?", uint(invitationID), ad.ID).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, `{"error": "invitation not found"}`, http.StatusNotFound)
+			} else {
+				logger.Error("failed to find invitation", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		result := db.Model(&models.Invitation{}).
+			Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+			Update("status", models.InvitationRevoked)
+		if result.Error != nil {
+			logger.Error("failed to revoke invitation", "error", result.Error, "invitation_id", invitation.ID)
+			http.Error(w, `{"error": "failed to revoke invitation"}`, http.StatusInternalServerError)
+			return
+		}
+		if result.RowsAffected == 0 {
+			http.Error(w, `{"error": "invitation is not pending"}`, http.StatusConflict)
+			return
+		}
+
+		logger.Info("invitation revoked", "invitation_id", invitation.ID, "ad_id", ad.ID)
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "invitation revoked"})
+	}
+}
+
+// myInvitation - приглашение текущего мастера по invitationID из URL, иначе ответ 401/400/404
+func myInvitation(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request) (*models.Invitation, bool) {
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	invitationID, err := strconv.ParseUint(chi.URLParam(r, "invitationID"), 10, 32)
+	if err != nil {
+		http.Error(w, `{"error": "invalid invitation id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	var invitation models.Invitation
+	if err := db.Where("id = ? AND worker_id = ?", uint(invitationID), userID).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error": "invitation not found"}`, http.StatusNotFound)
+		} else {
+			logger.Error("failed to find invitation", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &invitation, true
+}
+
+// MyInvitationsHandler - приглашения мастера (?status=) вместе с объявлениями, в том числе закрытыми
+func MyInvitationsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		type InvitationList struct {
+			ID            uint             `json:"id"`
+			Message       string           `json:"message"`
+			Status        string           `json:"status"`
+			ResponseID    *uint            `json:"response_id"`
+			CreatedAt     time.Time        `json:"created_at"`
+			AdID          uint             `json:"ad_id"`
+			AdTitle       string           `json:"ad_title"`
+			AdDescription string           `json:"ad_description"`
+			AdStatus      string           `json:"ad_status"`
+			AdVisibility  string           `json:"ad_visibility"`
+			BudgetMin     *float64         `json:"budget_min"`
+			BudgetMax     *float64         `json:"budget_max"`
+			Negotiable    bool             `json:"negotiable"`
+			Urgency       string           `json:"urgency"`
+			Location      string           `json:"location"`
+			DateFrom      *time.Time       `json:"date_from"`
+			DateTo        *time.Time       `json:"date_to"`
+			TimeSlots     models.TimeSlots `json:"time_slots"`
+			CategoryName  string           `json:"category_name"`
+			PriceUnitID   uint             `json:"price_unit_id"`
+			PriceUnitName string           `json:"price_unit_name"`
+			ClientName    string           `json:"client_name"`
+		}
+
+		var invitations []InvitationList
+		query := db.Table("invitations i").
+			Select("i.id, i.message, i.status, r.id as response_id, i.created_at, "+
+				"a.id as ad_id, a.title as ad_title, a.description as ad_description, a.status as ad_status, "+
+				"a.visibility as ad_visibility, a.budget_min, a.budget_max, a.negotiable, a.urgency, a.location, "+
+				"a.date_from, a.date_to, a.time_slots, c.name as category_name, "+
+				"pu.id as price_unit_id, pu.name as price_unit_name, u.name as client_name").
+			Joins("JOIN ads a ON a.id = i.ad_id AND a.deleted_at IS NULL").
+			Joins("JOIN categories c ON c.id = a.category_id").
+			Joins("JOIN price_units pu ON pu.id = a.price_unit_id").
+			Joins("JOIN users u ON u.id = a.user_id").
+			Joins("LEFT JOIN responses r ON r.invitation_id = i.id AND r.deleted_at IS NULL").
+			Where("i.worker_id = ? AND i.status <> ?", userID, models.InvitationRevoked).
+			Order("i.created_at DESC")
+		if status := r.URL.Query().Get("status"); status != "" {
+			query = query.Where("i.status = ?", status)
+		}
+		if err := query.Scan(&invitations).Error; err != nil {
+			logger.Error("failed to get my invitations", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"invitations": invitations,
+			"total":       len(invitations),
+		})
+	}
+}
+
+// AcceptInvitationHandler - принять приглашение: создаётся отклик, связанный с приглашением.
+// Публичность объявления и категории мастера не проверяются; тело {"message", "proposed_price"} необязательно
+func AcceptInvitationHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		invitation, ok := myInvitation(db, logger, w, r)
+		if !ok {
+			return
+		}
+		if !checkNotSuspended(db, logger, w, invitation.WorkerID, storage.SuspensionScopeResponses) {
+			return
+		}
+
+		var req struct {
+			Message       string   `json:"message"`
+			ProposedPrice *float64 `json:"proposed_price"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		var ad models.Ad
+		if err := db.First(&ad, invitation.AdID).Error; err != nil {
+			http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
+			return
+		}
+		if !invitable(&ad) {
+			http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+			return
+		}
+		if req.ProposedPrice != nil {
+			if msg := validateOfferPrice(&ad, *req.ProposedPrice, 0); msg != "" {
+				http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Текст отклика проходит автомодерацию, как при обычном отклике
+		verdict := moderation.Evaluate(db, moderation.Subject{
+			EntityType:  moderation.EntityResponse,
+			UserID:      invitation.WorkerID,
+			Text:        req.Message,
+			Price:       req.ProposedPrice,
+			CategoryID:  ad.CategoryID,
+			PriceUnitID: ad.PriceUnitID,
+		})
+
+		response := models.Response{
+			AdID:             ad.ID,
+			WorkerID:         invitation.WorkerID,
+			Message:          req.Message,
+			ProposedPrice:    req.ProposedPrice,
+			Status:           models.ResponseStatusPending,
+			CreatedAt:        time.Now(),
+			ModerationStatus: verdict.Status(),
+			InvitationID:     &invitation.ID,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Invitation{}).
+				Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+				Update("status", models.InvitationAccepted)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errInvitationNotPending
+			}
+			if err := tx.Create(&response).Error; err != nil {
+				return err
+			}
+			if req.ProposedPrice != nil {
+				if err := storage.PlaceOffer(tx, &models.Offer{
+					ResponseID:  response.ID,
+					AuthorID:    invitation.WorkerID,
+					Party:       models.OfferPartyWorker,
+					Price:       *req.ProposedPrice,
+					PriceUnitID: ad.PriceUnitID,
+					CreatedAt:   response.CreatedAt,
+				}); err != nil {
+					return err
+				}
+			}
+			message := fmt.Sprintf("Мастер принял приглашение и откликнулся на объявление «%s»", ad.Title)
+			return storage.Notify(tx, ad.UserID, storage.NotifyInvitationAccepted, message, &ad.ID)
+		})
+		switch {
+		case err == nil:
+		case errors.Is(err, errInvitationNotPending):
+			http.Error(w, `{"error": "invitation is not pending"}`, http.StatusConflict)
+			return
+		case storage.IsUniqueViolation(err):
+			http.Error(w, `{"error": "response already exists"}`, http.StatusConflict)
+			return
+		default:
+			logger.Error("failed to accept invitation", "error", err, "invitation_id", invitation.ID)
+			http.Error(w, `{"error": "failed to accept invitation"}`, http.StatusInternalServerError)
+			return
+		}
+		metrics.ResponsesCreated.Inc()
+
+		if err := moderation.Record(db, moderation.EntityResponse, response.ID, verdict); err != nil {
+			logger.Error("failed to record moderation decision", "error", err, "response_id", response.ID)
+		}
+
+		logger.Info("invitation accepted", "invitation_id", invitation.ID, "response_id", response.ID)
+
+		invitation.Status = models.InvitationAccepted
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"invitation": invitation,
+			"response":   response,
+		})
+	}
+}
+
+// DeclineInvitationHandler - отказаться от приглашения
+func DeclineInvitationHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		invitation, ok := myInvitation(db, logger, w, r)
+		if !ok {
+			return
+		}
+
+		var ad models.Ad
+		if err := db.Select("id, title").First(&ad, invitation.AdID).Error; err != nil {
+			http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Invitation{}).
+				Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+				Update("status", models.InvitationDeclined)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errInvitationNotPending
+			}
+			message := fmt.Sprintf("Мастер отказался от приглашения на объявление «%s»", ad.Title)
+			return storage.Notify(tx, invitation.ClientID, storage.NotifyInvitationDeclined, message, &ad.ID)
+		})
+		if errors.Is(err, errInvitationNotPending) {
+			http.Error(w, `{"error": "invitation is not pending"}`, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to decline invitation", "error", err, "invitation_id", invitation.ID)
+			http.Error(w, `{"error": "failed to decline invitation"}`, http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("invitation declined", "invitation_id", invitation.ID)
+
+		invitation.Status = models.InvitationDeclined
+		json.NewEncoder(w).Encode(invitation)
+	}
+}
@@ -0,0 +1,168 @@
+package ads
+
+import (
+	"encoding/json"
+	"errors"
+	"go-api/internal/cache"
+	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Действия владельца над жизненным циклом объявления (PATCH /my-ads/{adID}/{action})
+const (
+	ActionSubmit = "submit" // отправить черновик на модерацию
+	ActionPause  = "pause"  // временно скрыть опубликованное объявление
+	ActionResume = "resume" // вернуть скрытое объявление в публикацию
+	ActionClose  = "close"  // закрыть: мастер найден (filled) или заказ отменён (cancelled)
+	ActionRepost = "repost" // опубликовать истёкшее объявление повторно
+)
+
+// AdLifecycleHandler - смена статуса своего объявления владельцем. Переход допустим только
+// из определённых статусов (иначе 409 с текущим статусом); If-Match необязателен
+func AdLifecycleHandler(db *gorm.DB, logger *slog.Logger, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		adID, err := strconv.ParseUint(chi.URLParam(r, "adID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid ad id"}`, http.StatusBadRequest)
+			return
+		}
+
+		version, _, ok := cache.OptionalIfMatch(w, r)
+		if !ok {
+			return
+		}
+
+		var ad models.Ad
+		if err := db.Where("id = ? AND user_id = ?", uint(adID), userID).First(&ad).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, `{"error": "ad not found or access denied"}`, http.StatusNotFound)
+			} else {
+				logger.Error("failed to find ad", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// публикация (в том числе через модерацию) недоступна при ограничении на объявления
+		if action == ActionSubmit || action == ActionResume || action == ActionRepost {
+			if !checkNotSuspended(db, logger, w, userID, storage.SuspensionScopeAds) {
+				return
+			}
+		}
+
+		now := time.Now()
+		t := storage.Transition{
+			Model:   &models.Ad{},
+			Where:   "id = ? AND user_id = ?",
+			Args:    []interface{}{ad.ID, userID},
+			Version: version,
+		}
+		var verdict *moderation.Result
+
+		switch action {
+		case ActionSubmit:
+			res := moderation.Evaluate(db, adSubject(ad))
+			verdict = &res
+			t.From = []string{models.AdStatusDraft}
+			t.To = res.AdStatus()
+			if t.To == models.AdStatusActive {
+				t.Updates = storage.AdPublication(now)
+			}
+			// решение модерации принято по прочитанному содержимому: оно не должно измениться
+			if t.Version == 0 {
+				t.Version = ad.Version
+			}
+		case ActionPause:
+			t.From = []string{models.AdStatusActive}
+			t.To = models.AdStatusPaused
+		case ActionResume:
+			t.From = []string{models.AdStatusPaused}
+			t.To = models.AdStatusActive
+		case ActionClose:
+			var req struct {
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+				return
+			}
+			if req.Reason != models.AdCloseFilled && req.Reason != models.AdCloseCancelled {
+				http.Error(w, `{"error": "reason must be filled or cancelled"}`, http.StatusBadRequest)
+				return
+			}
+			// объявление на модерации не закрывают, а удаляют: закрытое считается прошедшим модерацию
+			t.From = []string{models.AdStatusActive, models.AdStatusPaused, models.AdStatusExpired}
+			t.To = models.AdStatusClosed
+			t.Updates = map[string]interface{}{"closed_reason": req.Reason, "closed_at": now}
+		case ActionRepost:
+			t.From = []string{models.AdStatusExpired}
+			t.To = models.AdStatusActive
+			t.Updates = storage.AdPublication(now)
+		}
+
+		var prev string
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if prev, err = storage.TransitionStatus(tx, t); err != nil || action != ActionClose {
+				return err
+			}
+			// торг по закрытому объявлению завершается; брони по отменённому заказу освобождают время
+			// мастеров, по выполненному (filled) - остаются
+			if err := storage.CloseAdOffers(tx, ad.ID); err != nil {
+				return err
+			}
+			if t.Updates["closed_reason"] == models.AdCloseCancelled {
+				return storage.CancelAdBookings(tx, ad.ID)
+			}
+			return nil
+		})
+		var conflict *storage.StatusConflict
+		var mismatch *storage.VersionMismatch
+		switch {
+		case err == nil:
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, `{"error": "ad not found or access denied"}`, http.StatusNotFound)
+			return
+		case errors.As(err, &mismatch):
+			cache.PreconditionFailed(w, mismatch.Version)
+			return
+		case errors.As(err, &conflict):
+			cache.StatusConflict(w, "ad", t.To, conflict.Status, conflict.Version)
+			return
+		default:
+			logger.Error("failed to change ad status", "error", err, "ad_id", ad.ID, "action", action)
+			http.Error(w, `{"error": "failed to change ad status"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if verdict != nil {
+			if err := moderation.Record(db, moderation.EntityAd, ad.ID, *verdict); err != nil {
+				logger.Error("failed to record moderation decision", "error", err, "ad_id", ad.ID)
+			}
+		}
+
+		logger.Info("ad status changed by owner", "ad_id", ad.ID, "action", action, "from", prev, "to", t.To)
+
+		db.Preload("Category").Preload("PriceUnit").Preload("User").First(&ad, ad.ID)
+		w.Header().Set("ETag", cache.VersionETag(ad.Version))
+		json.NewEncoder(w).Encode(ad)
+	}
+}
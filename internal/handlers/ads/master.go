@@ -2,7 +2,11 @@ package ads
 
 import (
 	"encoding/json"
+	"go-api/internal/metrics"
 	"go-api/internal/models"
+	"go-api/internal/moderation"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -15,6 +19,7 @@ import (
 // MasterResponsesHandler - управление откликами мастера
 func MasterResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userID, ok := r.Context().Value("user_id").(uint)
@@ -38,6 +43,10 @@ func MasterResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 
 // createResponse - создать отклик на объявление
 func createResponse(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, userID uint) {
+	if !checkNotSuspended(db, logger, w, userID, storage.SuspensionScopeResponses) {
+		return
+	}
+
 	// Проверяем, что у пользователя есть профиль мастера
 	var workerProfile models.WorkerProfile
 	if err := db.Where("user_id = ? AND have_worker_profile = ?", userID, true).First(&workerProfile).Error; err != nil {
@@ -70,6 +79,11 @@ func createResponse(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *
 		http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
 		return
 	}
+	// На закрытое объявление откликаются только через приглашение (PATCH /invitations/{id}/accept)
+	if ad.Visibility == models.AdVisibilityPrivate {
+		http.Error(w, `{"error": "ad not found"}`, http.StatusNotFound)
+		return
+	}
 
 	// Проверяем, что объявление не принадлежит мастеру
 	if ad.UserID == userID {
@@ -77,35 +91,91 @@ func createResponse(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *
 		return
 	}
 
-	// Проверяем, что категория объявления входит в категории мастера
-	var workerCategory models.WorkerCategory
-	if err := db.Where("worker_id = ? AND category_id = ?", userID, ad.CategoryID).First(&workerCategory).Error; err != nil {
+	// Откликнуться можно только на опубликованное объявление
+	if ad.Status != models.AdStatusActive {
+		http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+		return
+	}
+
+	// Проверяем, что категория объявления (или её родительская) входит в категории мастера
+	matches, err := storage.WorkerMatchesCategory(db, userID, ad.CategoryID)
+	if err != nil {
+		logger.Error("failed to match worker categories", "error", err)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !matches {
 		http.Error(w, `{"error": "ad category does not match worker categories"}`, http.StatusForbidden)
 		return
 	}
 
+	// Предложенная цена - первое предложение мастера в торге по отклику
+	if req.ProposedPrice != nil {
+		if msg := validateOfferPrice(&ad, *req.ProposedPrice, 0); msg != "" {
+			http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Проверяем, что мастер еще не откликнулся на это объявление
+	// (при одновременных запросах дубликат отсекает уникальный индекс, см. ниже)
 	var existingResponse models.Response
 	if err := db.Where("ad_id = ? AND worker_id = ?", req.AdID, userID).First(&existingResponse).Error; err == nil {
 		http.Error(w, `{"error": "response already exists"}`, http.StatusConflict)
 		return
 	}
 
+	// Автомодерация
+	verdict := moderation.Evaluate(db, moderation.Subject{
+		EntityType:  moderation.EntityResponse,
+		UserID:      userID,
+		Text:        req.Message,
+		Price:       req.ProposedPrice,
+		CategoryID:  ad.CategoryID,
+		PriceUnitID: ad.PriceUnitID,
+	})
+
 	// Создаем отклик
 	response := models.Response{
-		AdID:          req.AdID,
-		WorkerID:      userID,
-		Message:       req.Message,
-		ProposedPrice: req.ProposedPrice,
-		Status:        "pending",
-		CreatedAt:     time.Now(),
+		AdID:             req.AdID,
+		WorkerID:         userID,
+		Message:          req.Message,
+		ProposedPrice:    req.ProposedPrice,
+		Status:           models.ResponseStatusPending,
+		CreatedAt:        time.Now(),
+		ModerationStatus: verdict.Status(),
 	}
 
-	if err := db.Create(&response).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&response).Error; err != nil {
+			return err
+		}
+		if req.ProposedPrice == nil {
+			return nil
+		}
+		return storage.PlaceOffer(tx, &models.Offer{
+			ResponseID:  response.ID,
+			AuthorID:    userID,
+			Party:       models.OfferPartyWorker,
+			Price:       *req.ProposedPrice,
+			PriceUnitID: ad.PriceUnitID,
+			CreatedAt:   response.CreatedAt,
+		})
+	})
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			http.Error(w, `{"error": "response already exists"}`, http.StatusConflict)
+			return
+		}
 		logger.Error("failed to create response", "error", err)
 		http.Error(w, `{"error": "failed to create response"}`, http.StatusInternalServerError)
 		return
 	}
+	metrics.ResponsesCreated.Inc()
+
+	if err := moderation.Record(db, moderation.EntityResponse, response.ID, verdict); err != nil {
+		logger.Error("failed to record moderation decision", "error", err, "response_id", response.ID)
+	}
 
 	// Загружаем связанные данные для ответа
 	db.Preload("Ad").Preload("Ad.Category").Preload("Ad.User").First(&response, response.ID)
@@ -138,6 +208,7 @@ func getMyResponses(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *
 		AdTitle       string    `json:"ad_title"`
 		Message       string    `json:"message"`
 		ProposedPrice *float64  `json:"proposed_price"`
+		AgreedPrice   *float64  `json:"agreed_price"`
 		Status        string    `json:"status"`
 		CreatedAt     time.Time `json:"created_at"`
 		ClientName    string    `json:"client_name"`
@@ -146,7 +217,7 @@ func getMyResponses(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *
 
 	var responses []ResponseList
 	query := db.Table("responses r").
-		Select("r.id, r.ad_id, r.message, r.proposed_price, r.status, r.created_at, "+
+		Select("r.id, r.ad_id, r.message, r.proposed_price, r.agreed_price, r.status, r.created_at, "+
 			"a.title as ad_title, "+
 			"u.name as client_name, u.phone as client_phone").
 		Joins("JOIN ads a ON r.ad_id = a.id AND a.deleted_at IS NULL").
@@ -211,8 +282,8 @@ func deleteResponse(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *
 		return
 	}
 
-	// Мягкое удаление
-	if err := db.Delete(&response).Error; err != nil {
+	// Мягкое удаление; забронированное по отклику время освобождается, торг закрывается
+	if err := storage.DeleteResponse(db, &response); err != nil {
 		logger.Error("failed to delete response", "error", err)
 		http.Error(w, `{"error": "failed to delete response"}`, http.StatusInternalServerError)
 		return
@@ -0,0 +1,283 @@
+package ads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Торг по отклику: мастер и владелец объявления обмениваются предложениями цены и условий,
+// открыто только последнее; принятие предложения другой стороной принимает отклик
+
+const maxOfferTermsLength = 1000
+
+var (
+	errOfferNotOpen     = errors.New("offer is no longer open")
+	errCounterOfferOpen = errors.New("counter-offer is awaiting the worker")
+	errOfferUnitChanged = errors.New("offer price unit differs from the ad")
+)
+
+// validateOfferPrice проверяет цену предложения: больше нуля, в единице цены объявления
+// (priceUnitID 0 - единица объявления) и, если торг не предусмотрен, в пределах бюджета.
+// Возвращает текст ошибки для ответа 400 или пустую строку
+func validateOfferPrice(ad *models.Ad, price float64, priceUnitID uint) string {
+	if price <= 0 {
+		return "price must be greater than 0"
+	}
+	if priceUnitID != 0 && priceUnitID != ad.PriceUnitID {
+		return "price_unit_id must match the ad price unit"
+	}
+	if ad.Negotiable {
+		return ""
+	}
+	if (ad.BudgetMin != nil && price < *ad.BudgetMin) || (ad.BudgetMax != nil && price > *ad.BudgetMax) {
+		return "price must be within the ad budget"
+	}
+	return ""
+}
+
+// lockPendingResponse блокирует отклик до конца транзакции, если он ещё ожидает решения
+func lockPendingResponse(tx *gorm.DB, responseID uint) error {
+	var response models.Response
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ? AND status = ?", responseID, models.ResponseStatusPending).Take(&response).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errResponseNotPending
+	}
+	return err
+}
+
+// responseParty - отклик по responseID из URL и сторона текущего пользователя: мастер, оставивший
+// отклик, или владелец объявления (ему виден только отклик, прошедший модерацию), иначе ответ 401/400/404
+func responseParty(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request) (*models.Response, string, bool) {
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+		return nil, "", false
+	}
+	responseID, err := strconv.ParseUint(chi.URLParam(r, "responseID"), 10, 32)
+	if err != nil {
+		http.Error(w, `{"error": "invalid response id"}`, http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	var response models.Response
+	if err := db.Preload("Ad").First(&response, uint(responseID)).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("failed to find response", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return nil, "", false
+		}
+	} else if response.WorkerID == userID {
+		return &response, models.OfferPartyWorker, true
+	} else if response.Ad.UserID == userID && response.ModerationStatus == "approved" {
+		return &response, models.OfferPartyClient, true
+	}
+	http.Error(w, `{"error": "response not found or access denied"}`, http.StatusNotFound)
+	return nil, "", false
+}
+
+// counterparty - получатель уведомлений о действиях стороны party
+func counterparty(response *models.Response, party string) uint {
+	if party == models.OfferPartyWorker {
+		return response.Ad.UserID
+	}
+	return response.WorkerID
+}
+
+// OffersHandler - история предложений по отклику (для мастера и владельца объявления)
+func OffersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		response, _, ok := responseParty(db, logger, w, r)
+		if !ok {
+			return
+		}
+
+		offers, err := storage.OfferHistory(db, response.ID)
+		if err != nil {
+			logger.Error("failed to get offers", "error", err, "response_id", response.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"offers":          offers,
+			"total":           len(offers),
+			"response_status": response.Status,
+			"agreed_price":    response.AgreedPrice,
+		})
+	}
+}
+
+// CreateOfferHandler - новое предложение цены и условий по отклику, ожидающему решения.
+// Открытое предложение (своё или другой стороны) при этом становится superseded
+func CreateOfferHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		response, party, ok := responseParty(db, logger, w, r)
+		if !ok {
+			return
+		}
+		userID := r.Context().Value("user_id").(uint)
+		if party == models.OfferPartyWorker && !checkNotSuspended(db, logger, w, userID, storage.SuspensionScopeResponses) {
+			return
+		}
+
+		var req struct {
+			Price       *float64 `json:"price"`
+			PriceUnitID uint     `json:"price_unit_id"`
+			Terms       string   `json:"terms"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.Price == nil {
+			http.Error(w, `{"error": "price is required"}`, http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(req.Terms) > maxOfferTermsLength {
+			http.Error(w, `{"error": "terms must be at most 1000 characters"}`, http.StatusBadRequest)
+			return
+		}
+
+		ad := &response.Ad
+		if ad.Status != models.AdStatusActive && ad.Status != models.AdStatusPaused {
+			http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+			return
+		}
+		if req.PriceUnitID != 0 {
+			req.PriceUnitID = storage.ResolveRedirect(db, storage.RedirectPriceUnit, req.PriceUnitID)
+		}
+		if msg := validateOfferPrice(ad, *req.Price, req.PriceUnitID); msg != "" {
+			http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		offer := models.Offer{
+			ResponseID:  response.ID,
+			AuthorID:    userID,
+			Party:       party,
+			Price:       *req.Price,
+			PriceUnitID: ad.PriceUnitID,
+			Terms:       req.Terms,
+			CreatedAt:   time.Now(),
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := lockPendingResponse(tx, response.ID); err != nil {
+				return err
+			}
+			if err := storage.PlaceOffer(tx, &offer); err != nil {
+				return err
+			}
+			message := fmt.Sprintf("Новое предложение по отклику на объявление «%s»: %.2f", ad.Title, offer.Price)
+			return storage.Notify(tx, counterparty(response, party), storage.NotifyOfferReceived, message, &ad.ID)
+		})
+		if errors.Is(err, errResponseNotPending) {
+			http.Error(w, `{"error": "response is not pending"}`, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to create offer", "error", err, "response_id", response.ID)
+			http.Error(w, `{"error": "failed to create offer"}`, http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("offer created", "offer_id", offer.ID, "response_id", response.ID, "party", party)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(offer)
+	}
+}
+
+// AcceptOfferHandler - принять открытое предложение другой стороны: отклик принимается, цена
+// предложения становится согласованной. Необязательное тело {"starts_at", "ends_at"} бронирует
+// время мастера, как при принятии отклика
+func AcceptOfferHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		response, party, ok := responseParty(db, logger, w, r)
+		if !ok {
+			return
+		}
+		offerID, err := strconv.ParseUint(chi.URLParam(r, "offerID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid offer id"}`, http.StatusBadRequest)
+			return
+		}
+		startsAt, endsAt, ok := decodeSlot(w, r)
+		if !ok {
+			return
+		}
+
+		ad := &response.Ad
+		if ad.Status != models.AdStatusActive && ad.Status != models.AdStatusPaused {
+			http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+			return
+		}
+
+		var offer models.Offer
+		if err := db.Where("id = ? AND response_id = ?", uint(offerID), response.ID).First(&offer).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, `{"error": "offer not found"}`, http.StatusNotFound)
+			} else {
+				logger.Error("failed to find offer", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			}
+			return
+		}
+		if offer.Party == party {
+			http.Error(w, `{"error": "cannot accept own offer"}`, http.StatusConflict)
+			return
+		}
+
+		var booking *models.Booking
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if _, booking, err = acceptResponse(tx, ad, response, offer.ID, startsAt, endsAt); err != nil {
+				return err
+			}
+			details := acceptanceDetails(&offer, booking)
+			if party == models.OfferPartyClient {
+				message := fmt.Sprintf("Ваш отклик на объявление «%s» принят", ad.Title) + details
+				return storage.Notify(tx, response.WorkerID, storage.NotifyResponseAccepted, message, &ad.ID)
+			}
+			message := fmt.Sprintf("Мастер принял ваше предложение по объявлению «%s»", ad.Title) + details
+			return storage.Notify(tx, ad.UserID, storage.NotifyOfferAccepted, message, &ad.ID)
+		})
+		if err != nil {
+			writeAcceptError(w, logger, err, response.ID)
+			return
+		}
+
+		logger.Info("offer accepted", "offer_id", offer.ID, "response_id", response.ID, "party", party, "booked", booking != nil)
+
+		offer.Status = models.OfferAccepted
+		response.Status = models.ResponseStatusAccepted
+		response.AgreedPrice = &offer.Price
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response": response,
+			"offer":    offer,
+			"booking":  booking,
+		})
+	}
+}
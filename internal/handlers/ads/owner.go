@@ -0,0 +1,357 @@
+package ads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Отклики на объявление глазами его владельца
+
+const maxBookingDuration = 12 * time.Hour
+
+var errResponseNotPending = errors.New("response is not pending")
+
+// ownAd - объявление текущего пользователя по adID из URL, иначе ответ 401/400/404
+func ownAd(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request) (*models.Ad, bool) {
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	adID, err := strconv.ParseUint(chi.URLParam(r, "adID"), 10, 32)
+	if err != nil {
+		http.Error(w, `{"error": "invalid ad id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	var ad models.Ad
+	if err := db.Where("id = ? AND user_id = ?", uint(adID), userID).First(&ad).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error": "ad not found or access denied"}`, http.StatusNotFound)
+		} else {
+			logger.Error("failed to find ad", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &ad, true
+}
+
+// adResponse - отклик (прошедший модерацию) на объявление по responseID из URL, иначе ответ 400/404
+func adResponse(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, adID uint) (*models.Response, bool) {
+	responseID, err := strconv.ParseUint(chi.URLParam(r, "responseID"), 10, 32)
+	if err != nil {
+		http.Error(w, `{"error": "invalid response id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	var response models.Response
+	if err := db.Where("id = ? AND ad_id = ? AND moderation_status = ?", uint(responseID), adID, "approved").
+		First(&response).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error": "response not found"}`, http.StatusNotFound)
+		} else {
+			logger.Error("failed to find response", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &response, true
+}
+
+// AdResponsesHandler - отклики на своё объявление (?status=pending|accepted|rejected) с бронями
+func AdResponsesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+
+		type ResponseList struct {
+			ID            uint            `json:"id"`
+			WorkerID      uint            `json:"worker_id"`
+			WorkerName    string          `json:"worker_name"`
+			WorkerPhone   string          `json:"worker_phone"`
+			Message       string          `json:"message"`
+			ProposedPrice *float64        `json:"proposed_price"`
+			AgreedPrice   *float64        `json:"agreed_price"`
+			Status        string          `json:"status"`
+			CreatedAt     time.Time       `json:"created_at"`
+			Booking       *models.Booking `json:"booking,omitempty" gorm:"-"`
+		}
+
+		var responses []ResponseList
+		query := db.Table("responses r").
+			Select("r.id, r.worker_id, u.name as worker_name, u.phone as worker_phone, r.message, r.proposed_price, r.agreed_price, r.status, r.created_at").
+			Joins("JOIN users u ON r.worker_id = u.id AND u.deleted_at IS NULL").
+			Where("r.ad_id = ? AND r.moderation_status = ? AND r.deleted_at IS NULL", ad.ID, "approved").
+			Order("r.created_at DESC")
+		if status := r.URL.Query().Get("status"); status != "" {
+			query = query.Where("r.status = ?", status)
+		}
+		if err := query.Scan(&responses).Error; err != nil {
+			logger.Error("failed to get ad responses", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		var bookings []models.Booking
+		if err := db.Where("ad_id = ? AND status = ?", ad.ID, models.BookingActive).Find(&bookings).Error; err != nil {
+			logger.Error("failed to get ad bookings", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		for i := range bookings {
+			for j := range responses {
+				if responses[j].ID == bookings[i].ResponseID {
+					responses[j].Booking = &bookings[i]
+				}
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"responses": responses,
+			"total":     len(responses),
+		})
+	}
+}
+
+// AcceptResponseHandler - принять отклик. Если последнее предложение по отклику сделал мастер, его цена
+// становится согласованной; пока открыто встречное предложение клиента, принять отклик нельзя (409).
+// Необязательное тело {"starts_at", "ends_at"} бронирует это время мастера: оно должно быть
+// в его расписании и не пересекаться с другими бронями (иначе 409)
+func AcceptResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+		startsAt, endsAt, ok := decodeSlot(w, r)
+		if !ok {
+			return
+		}
+
+		// Принять отклик можно, пока объявление опубликовано или скрыто на время
+		if ad.Status != models.AdStatusActive && ad.Status != models.AdStatusPaused {
+			http.Error(w, `{"error": "ad is not accepting responses", "status": "`+ad.Status+`"}`, http.StatusConflict)
+			return
+		}
+
+		response, ok := adResponse(db, logger, w, r, ad.ID)
+		if !ok {
+			return
+		}
+
+		var offer *models.Offer
+		var booking *models.Booking
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			offer, booking, err = acceptResponse(tx, ad, response, 0, startsAt, endsAt)
+			if err != nil {
+				return err
+			}
+			message := fmt.Sprintf("Ваш отклик на объявление «%s» принят", ad.Title) + acceptanceDetails(offer, booking)
+			return storage.Notify(tx, response.WorkerID, storage.NotifyResponseAccepted, message, &ad.ID)
+		})
+		if err != nil {
+			writeAcceptError(w, logger, err, response.ID)
+			return
+		}
+
+		logger.Info("response accepted", "response_id", response.ID, "ad_id", ad.ID, "booked", booking != nil)
+
+		response.Status = models.ResponseStatusAccepted
+		if offer != nil {
+			response.AgreedPrice = &offer.Price
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response": response,
+			"booking":  booking,
+		})
+	}
+}
+
+// decodeSlot - необязательное тело {"starts_at", "ends_at"} с временем брони мастера, иначе ответ 400
+func decodeSlot(w http.ResponseWriter, r *http.Request) (*time.Time, *time.Time, bool) {
+	var req struct {
+		StartsAt *time.Time `json:"starts_at"`
+		EndsAt   *time.Time `json:"ends_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if (req.StartsAt == nil) != (req.EndsAt == nil) {
+		http.Error(w, `{"error": "starts_at and ends_at must be set together"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if req.StartsAt != nil {
+		switch {
+		case !req.EndsAt.After(*req.StartsAt):
+			http.Error(w, `{"error": "ends_at must be after starts_at"}`, http.StatusBadRequest)
+			return nil, nil, false
+		case req.EndsAt.Sub(*req.StartsAt) > maxBookingDuration:
+			http.Error(w, `{"error": "booking must be at most 12 hours"}`, http.StatusBadRequest)
+			return nil, nil, false
+		case req.StartsAt.Before(time.Now()):
+			http.Error(w, `{"error": "starts_at must be in the future"}`, http.StatusBadRequest)
+			return nil, nil, false
+		}
+	}
+	return req.StartsAt, req.EndsAt, true
+}
+
+// acceptResponse принимает отклик в транзакции. Согласованная цена фиксируется по открытому
+// предложению: offerID != 0 - принимается именно оно, иначе (владелец принимает отклик) -
+// последнее предложение мастера. startsAt/endsAt, если заданы, бронируют время мастера.
+// Ошибки: errResponseNotPending, errOfferNotOpen, errCounterOfferOpen, errOfferUnitChanged,
+// *storage.SlotConflict, storage.ErrSlotUnavailable
+func acceptResponse(tx *gorm.DB, ad *models.Ad, response *models.Response, offerID uint, startsAt, endsAt *time.Time) (*models.Offer, *models.Booking, error) {
+	// блокировка отклика отсекает повторное или одновременное решение и новое предложение
+	if err := lockPendingResponse(tx, response.ID); err != nil {
+		return nil, nil, err
+	}
+
+	offer, err := storage.OpenOffer(tx, response.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case offerID != 0 && (offer == nil || offer.ID != offerID):
+		return nil, nil, errOfferNotOpen
+	case offerID == 0 && offer != nil && offer.Party == models.OfferPartyClient:
+		return nil, nil, errCounterOfferOpen
+	case offer != nil && offer.PriceUnitID != ad.PriceUnitID:
+		return nil, nil, errOfferUnitChanged
+	}
+
+	updates := map[string]interface{}{"status": models.ResponseStatusAccepted}
+	if offer != nil {
+		if err := tx.Model(offer).Update("status", models.OfferAccepted).Error; err != nil {
+			return nil, nil, err
+		}
+		updates["agreed_price"] = offer.Price
+	}
+	if err := tx.Model(&models.Response{}).Where("id = ?", response.ID).Updates(updates).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if startsAt == nil {
+		return offer, nil, nil
+	}
+	booking := &models.Booking{
+		WorkerID:   response.WorkerID,
+		ClientID:   ad.UserID,
+		AdID:       ad.ID,
+		ResponseID: response.ID,
+		StartsAt:   *startsAt,
+		EndsAt:     *endsAt,
+	}
+	if err := storage.ReserveSlot(tx, booking); err != nil {
+		return nil, nil, err
+	}
+	return offer, booking, nil
+}
+
+// acceptanceDetails - согласованная цена и забронированное время для текста уведомления
+func acceptanceDetails(offer *models.Offer, booking *models.Booking) string {
+	var details string
+	if offer != nil {
+		details += fmt.Sprintf(", согласованная цена %.2f", offer.Price)
+	}
+	if booking != nil {
+		details += fmt.Sprintf(", забронировано время %s – %s (UTC)",
+			booking.StartsAt.UTC().Format("02.01.2006 15:04"), booking.EndsAt.UTC().Format("15:04"))
+	}
+	return details
+}
+
+// writeAcceptError - ответ на ошибку acceptResponse
+func writeAcceptError(w http.ResponseWriter, logger *slog.Logger, err error, responseID uint) {
+	var conflict *storage.SlotConflict
+	switch {
+	case errors.Is(err, errResponseNotPending):
+		http.Error(w, `{"error": "response is not pending"}`, http.StatusConflict)
+	case errors.Is(err, errOfferNotOpen):
+		http.Error(w, `{"error": "offer is no longer open"}`, http.StatusConflict)
+	case errors.Is(err, errCounterOfferOpen):
+		http.Error(w, `{"error": "counter-offer is awaiting the worker's answer"}`, http.StatusConflict)
+	case errors.Is(err, errOfferUnitChanged):
+		http.Error(w, `{"error": "offer price unit no longer matches the ad, make a new offer"}`, http.StatusConflict)
+	case errors.As(err, &conflict):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    "slot overlaps another booking",
+			"conflict": map[string]time.Time{"starts_at": conflict.Booking.StartsAt, "ends_at": conflict.Booking.EndsAt},
+		})
+	case errors.Is(err, storage.ErrSlotUnavailable):
+		http.Error(w, `{"error": "slot is outside worker availability"}`, http.StatusConflict)
+	default:
+		logger.Error("failed to accept response", "error", err, "response_id", responseID)
+		http.Error(w, `{"error": "failed to accept response"}`, http.StatusInternalServerError)
+	}
+}
+
+// RejectResponseHandler - отклонить отклик, ожидающий решения
+func RejectResponseHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+		response, ok := adResponse(db, logger, w, r, ad.ID)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Response{}).
+				Where("id = ? AND status = ?", response.ID, models.ResponseStatusPending).
+				Update("status", models.ResponseStatusRejected)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errResponseNotPending
+			}
+			if err := storage.CloseOffers(tx, response.ID); err != nil {
+				return err
+			}
+			message := fmt.Sprintf("Ваш отклик на объявление «%s» отклонён", ad.Title)
+			return storage.Notify(tx, response.WorkerID, storage.NotifyResponseRejected, message, &ad.ID)
+		})
+		if errors.Is(err, errResponseNotPending) {
+			http.Error(w, `{"error": "response is not pending"}`, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to reject response", "error", err, "response_id", response.ID)
+			http.Error(w, `{"error": "failed to reject response"}`, http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("response rejected", "response_id", response.ID, "ad_id", ad.ID)
+
+		response.Status = models.ResponseStatusRejected
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,382 @@
+package ads
+
+import (
+	"encoding/json"
+	"go-api/internal/matching"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Рекомендации: подходящие объявления для мастера и подходящие мастера для объявления (internal/matching)
+
+const (
+	maxMatchCandidates  = 100 // сколько кандидатов оценивается за запрос
+	maxRecommendedLimit = 50
+)
+
+// recommendedLimit - ?limit= (по умолчанию 10, не больше 50)
+func recommendedLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return 10
+	}
+	if limit > maxRecommendedLimit {
+		return maxRecommendedLimit
+	}
+	return limit
+}
+
+// adAvailability - свободные дни мастера в окне объявления по его календарю
+func adAvailability(pair *matching.Pair, calendar *storage.Calendar, ad *models.Ad, now time.Time) {
+	pair.HasSchedule = len(calendar.Weekly) > 0
+	from, to := matching.Window(ad, now, calendar.Location)
+	pair.AvailableDays, pair.WindowDays = matching.AvailableDays(calendar.Free(from, to), ad.TimeSlots, from, to, calendar.Location)
+}
+
+// windowBounds - период, покрывающий окна всех объявлений при любом часовом поясе мастера
+func windowBounds(ads []models.Ad, now time.Time) (time.Time, time.Time) {
+	from, to := now, now
+	for i := range ads {
+		adFrom, adTo := matching.Window(&ads[i], now, time.UTC)
+		if adFrom.Before(from) {
+			from = adFrom
+		}
+		if adTo.After(to) {
+			to = adTo
+		}
+	}
+	return from.Add(-24 * time.Hour), to.Add(24 * time.Hour)
+}
+
+// RecommendedAdsHandler - опубликованные объявления в категориях мастера, на которые он ещё не откликался,
+// по убыванию оценки подбора (?limit=)
+func RecommendedAdsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		var profile models.WorkerProfile
+		if err := db.Where("user_id = ? AND have_worker_profile = ?", userID, true).First(&profile).Error; err != nil {
+			http.Error(w, `{"error": "worker profile not found"}`, http.StatusForbidden)
+			return
+		}
+		limit := recommendedLimit(r)
+
+		type RecommendedAd struct {
+			ID            uint             `json:"id"`
+			Title         string           `json:"title"`
+			BudgetMin     *float64         `json:"budget_min"`
+			BudgetMax     *float64         `json:"budget_max"`
+			Negotiable    bool             `json:"negotiable"`
+			Urgency       string           `json:"urgency"`
+			Location      string           `json:"location"`
+			DateFrom      *time.Time       `json:"date_from"`
+			DateTo        *time.Time       `json:"date_to"`
+			TimeSlots     models.TimeSlots `json:"time_slots"`
+			CreatedAt     time.Time        `json:"created_at"`
+			CategoryID    uint             `json:"category_id"`
+			CategoryName  string           `json:"category_name"`
+			PriceUnitID   uint             `json:"price_unit_id"`
+			PriceUnitName string           `json:"price_unit_name"`
+			matching.Match
+		}
+		result := []RecommendedAd{}
+
+		var categoryIDs []uint
+		if err := db.Model(&models.WorkerCategory{}).Where("worker_id = ?", userID).Pluck("category_id", &categoryIDs).Error; err != nil {
+			logger.Error("failed to get worker categories", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		var ads []models.Ad
+		distances := map[uint]int{}
+		if len(categoryIDs) > 0 {
+			var err error
+			if distances, err = storage.CategoryDistances(db, categoryIDs...); err != nil {
+				logger.Error("failed to get category subtree", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+				return
+			}
+			adCategories := make([]uint, 0, len(distances))
+			for id := range distances {
+				adCategories = append(adCategories, id)
+			}
+			if err := db.Preload("Category").Preload("PriceUnit").
+				Where("status = ? AND visibility = ? AND user_id <> ? AND category_id IN ?",
+					models.AdStatusActive, models.AdVisibilityPublic, userID, adCategories).
+				Where("NOT EXISTS (SELECT 1 FROM responses r WHERE r.ad_id = ads.id AND r.worker_id = ? AND r.deleted_at IS NULL)", userID).
+				Order("COALESCE(published_at, created_at) DESC").
+				Limit(maxMatchCandidates).
+				Find(&ads).Error; err != nil {
+				logger.Error("failed to get candidate ads", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		if len(ads) == 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ads": result, "total": 0})
+			return
+		}
+
+		adCategories := make([]uint, 0, len(ads))
+		for _, ad := range ads {
+			adCategories = append(adCategories, ad.CategoryID)
+		}
+		now := time.Now()
+		from, to := windowBounds(ads, now)
+		stats, err := storage.WorkerStatsByID(db, []uint{userID})
+		if err != nil {
+			logger.Error("failed to get worker stats", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		prices, err := storage.TypicalPrices(db, []uint{userID}, adCategories)
+		if err != nil {
+			logger.Error("failed to get typical prices", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		calendar, err := storage.WorkerCalendar(db, userID, from, to)
+		if err != nil {
+			logger.Error("failed to get worker calendar", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		workerStats := stats[userID]
+		for i := range ads {
+			ad := &ads[i]
+			pair := matching.Pair{
+				CategoryDistance: distances[ad.CategoryID],
+				AdLocation:       ad.Location,
+				WorkerLocation:   profile.Location,
+				RatingAvg:        workerStats.RatingAvg,
+				RatingCount:      workerStats.RatingCount,
+				Accepted:         workerStats.Accepted,
+				Rejected:         workerStats.Rejected,
+				BudgetMin:        ad.BudgetMin,
+				BudgetMax:        ad.BudgetMax,
+				Negotiable:       ad.Negotiable,
+			}
+			if typical, ok := prices[storage.PriceKey{WorkerID: userID, CategoryID: ad.CategoryID, PriceUnitID: ad.PriceUnitID}]; ok {
+				pair.TypicalPrice = &typical
+			}
+			adAvailability(&pair, calendar, ad, now)
+
+			result = append(result, RecommendedAd{
+				ID:            ad.ID,
+				Title:         ad.Title,
+				BudgetMin:     ad.BudgetMin,
+				BudgetMax:     ad.BudgetMax,
+				Negotiable:    ad.Negotiable,
+				Urgency:       ad.Urgency,
+				Location:      ad.Location,
+				DateFrom:      ad.DateFrom,
+				DateTo:        ad.DateTo,
+				TimeSlots:     ad.TimeSlots,
+				CreatedAt:     ad.CreatedAt,
+				CategoryID:    ad.CategoryID,
+				CategoryName:  ad.Category.Name,
+				PriceUnitID:   ad.PriceUnitID,
+				PriceUnitName: ad.PriceUnit.Name,
+				Match:         matching.Score(pair),
+			})
+		}
+
+		// при равной оценке - более свежие объявления (порядок кандидатов)
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+		if len(result) > limit {
+			result = result[:limit]
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ads":   result,
+			"total": len(result),
+		})
+	}
+}
+
+// RecommendedWorkersHandler - одобренные мастера, указавшие категорию объявления (или родительскую)
+// и ещё не откликнувшиеся на него, по убыванию оценки подбора (?limit=)
+func RecommendedWorkersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		ad, ok := ownAd(db, logger, w, r)
+		if !ok {
+			return
+		}
+		limit := recommendedLimit(r)
+
+		distances, err := storage.CategoryAncestorDistances(db, ad.CategoryID)
+		if err != nil {
+			logger.Error("failed to get category ancestors", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		ancestors := make([]uint, 0, len(distances))
+		for id := range distances {
+			ancestors = append(ancestors, id)
+		}
+
+		type RecommendedWorker struct {
+			ID          uint     `json:"id"`
+			Name        string   `json:"name"`
+			Location    string   `json:"location"`
+			ExpYears    *int     `json:"exp_years"`
+			Rating      *float64 `json:"rating"`
+			ReviewCount int      `json:"review_count"`
+			matching.Match
+		}
+		// кандидаты - мастера категории объявления или её родительских. Оцениваются не больше maxMatchCandidates,
+		// отобранных предварительной оценкой в SQL: ближайшая категория, совпадение локации, средний рейтинг
+		distanceSQL := "CASE wc.category_id"
+		var distanceArgs []interface{}
+		for id, d := range distances {
+			distanceSQL += " WHEN ? THEN ?"
+			distanceArgs = append(distanceArgs, id, d)
+		}
+		distanceSQL += " END"
+		adLocation := strings.ToLower(strings.TrimSpace(ad.Location))
+
+		var links []struct {
+			WorkerID uint
+			Distance int
+		}
+		if err := db.Table("worker_categories wc").
+			Select("wc.worker_id, MIN("+distanceSQL+") as distance", distanceArgs...).
+			Joins("JOIN worker_profiles wp ON wp.user_id = wc.worker_id AND wp.deleted_at IS NULL").
+			Joins("JOIN users u ON u.id = wc.worker_id AND u.deleted_at IS NULL").
+			Joins("LEFT JOIN (SELECT worker_id, AVG(rating) as avg FROM reviews WHERE deleted_at IS NULL GROUP BY worker_id) rv ON rv.worker_id = wc.worker_id").
+			Where("wc.category_id IN ? AND wp.have_worker_profile = ? AND wp.status = ? AND wc.worker_id <> ?",
+				ancestors, true, "approved", ad.UserID).
+			Where("NOT EXISTS (SELECT 1 FROM responses r WHERE r.ad_id = ? AND r.worker_id = wc.worker_id AND r.deleted_at IS NULL)", ad.ID).
+			Group("wc.worker_id, wp.location, rv.avg").
+			Order(clause.Expr{
+				SQL:  "distance, (? <> '' AND LOWER(TRIM(wp.location)) = ?) DESC, COALESCE(rv.avg, 0) DESC, wc.worker_id",
+				Vars: []interface{}{adLocation, adLocation},
+			}).
+			Limit(maxMatchCandidates).
+			Scan(&links).Error; err != nil {
+			logger.Error("failed to get candidate workers", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		// у мастера учитывается ближайшая к объявлению категория
+		closest := make(map[uint]int, len(links))
+		workerIDs := make([]uint, 0, len(links))
+		for _, link := range links {
+			closest[link.WorkerID] = link.Distance
+			workerIDs = append(workerIDs, link.WorkerID)
+		}
+
+		result := []RecommendedWorker{}
+		if len(workerIDs) == 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"workers": result, "total": 0})
+			return
+		}
+
+		var candidates []struct {
+			ID       uint
+			Name     string
+			Location string
+			ExpYears *int
+		}
+		if err := db.Table("users u").
+			Select("u.id, u.name, wp.location, wp.exp_years").
+			Joins("JOIN worker_profiles wp ON wp.user_id = u.id").
+			Where("u.id IN ?", workerIDs).
+			Order("u.id").
+			Scan(&candidates).Error; err != nil {
+			logger.Error("failed to get candidate workers", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		stats, err := storage.WorkerStatsByID(db, workerIDs)
+		if err != nil {
+			logger.Error("failed to get worker stats", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		prices, err := storage.TypicalPrices(db, workerIDs, []uint{ad.CategoryID})
+		if err != nil {
+			logger.Error("failed to get typical prices", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		from, to := windowBounds([]models.Ad{*ad}, now)
+		calendars, err := storage.WorkerCalendars(db, workerIDs, from, to)
+		if err != nil {
+			logger.Error("failed to get worker calendars", "error", err, "ad_id", ad.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		for _, c := range candidates {
+			calendar, ok := calendars[c.ID]
+			if !ok {
+				continue
+			}
+			workerStats := stats[c.ID]
+			pair := matching.Pair{
+				CategoryDistance: closest[c.ID],
+				AdLocation:       ad.Location,
+				WorkerLocation:   c.Location,
+				RatingAvg:        workerStats.RatingAvg,
+				RatingCount:      workerStats.RatingCount,
+				Accepted:         workerStats.Accepted,
+				Rejected:         workerStats.Rejected,
+				BudgetMin:        ad.BudgetMin,
+				BudgetMax:        ad.BudgetMax,
+				Negotiable:       ad.Negotiable,
+			}
+			if typical, ok := prices[storage.PriceKey{WorkerID: c.ID, CategoryID: ad.CategoryID, PriceUnitID: ad.PriceUnitID}]; ok {
+				pair.TypicalPrice = &typical
+			}
+			adAvailability(&pair, calendar, ad, now)
+
+			worker := RecommendedWorker{
+				ID:          c.ID,
+				Name:        c.Name,
+				Location:    c.Location,
+				ExpYears:    c.ExpYears,
+				ReviewCount: workerStats.RatingCount,
+				Match:       matching.Score(pair),
+			}
+			if workerStats.RatingCount > 0 {
+				rating := workerStats.RatingAvg
+				worker.Rating = &rating
+			}
+			result = append(result, worker)
+		}
+
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+		if len(result) > limit {
+			result = result[:limit]
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"workers": result,
+			"total":   len(result),
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ads
 
 import (
 	"go-api/internal/middleware"
+	"go-api/internal/ratelimit"
 	"log/slog"
 
 	"github.com/go-chi/chi/v5"
@@ -12,26 +13,63 @@ func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
 	public := chi.NewRouter()
 	protected := chi.NewRouter()
 	master := chi.NewRouter()
+	invitations := chi.NewRouter()
+
+	// создание объявлений и откликов ограничено по пользователю
+	createLimit := middleware.RateLimit(ratelimit.GroupCreate, logger)
 
 	//  ПУБЛИЧНЫЕ (мастера смотрят без токена)
 	public.Get("/", PublicAdsHandler(db, logger))       // GET /ads - список всех
 	public.Get("/{adID}", PublicAdsHandler(db, logger)) // GET /ads/123 - конкретное объявление
 
 	//  ЗАЩИЩЁННЫЕ (клиент управляет своими объявлениями)
-	protected.Use(middleware.AuthMiddleware(logger))
-	protected.Get("/", ProtectedAdsHandler(db, logger))          // GET /my-ads - мои объявления
-	protected.Get("/{adID}", ProtectedAdsHandler(db, logger))    // GET /my-ads/123 - моё объявление
-	protected.Post("/", ProtectedAdsHandler(db, logger))         // POST /my-ads - создать
-	protected.Patch("/{adID}", ProtectedAdsHandler(db, logger))  // PATCH /my-ads/123 - обновить
-	protected.Delete("/{adID}", ProtectedAdsHandler(db, logger)) // DELETE /my-ads/123 - удалить
+	protected.Use(middleware.AuthMiddleware(db, logger))
+	protected.Use(middleware.Idempotency(db, logger))                      // повтор POST с тем же Idempotency-Key не создаёт дубликат
+	protected.Get("/", ProtectedAdsHandler(db, logger))                    // GET /my-ads - мои объявления
+	protected.Get("/{adID}", ProtectedAdsHandler(db, logger))              // GET /my-ads/123 - моё объявление
+	protected.With(createLimit).Post("/", ProtectedAdsHandler(db, logger)) // POST /my-ads - создать
+	protected.Patch("/{adID}", ProtectedAdsHandler(db, logger))            // PATCH /my-ads/123 - обновить
+	protected.Delete("/{adID}", ProtectedAdsHandler(db, logger))           // DELETE /my-ads/123 - удалить
+
+	// жизненный цикл: PATCH /my-ads/123/submit | pause | resume | close | repost
+	for _, action := range []string{ActionSubmit, ActionPause, ActionResume, ActionClose, ActionRepost} {
+		protected.Patch("/{adID}/"+action, AdLifecycleHandler(db, logger, action))
+	}
+
+	// отклики на своё объявление: список, принять (с бронью времени мастера), отклонить
+	protected.Get("/{adID}/responses", AdResponsesHandler(db, logger))                          // GET /my-ads/123/responses
+	protected.Patch("/{adID}/responses/{responseID}/accept", AcceptResponseHandler(db, logger)) // PATCH /my-ads/123/responses/7/accept
+	protected.Patch("/{adID}/responses/{responseID}/reject", RejectResponseHandler(db, logger)) // PATCH /my-ads/123/responses/7/reject
+
+	protected.Get("/{adID}/recommended-workers", RecommendedWorkersHandler(db, logger)) // GET /my-ads/123/recommended-workers - подходящие мастера
+
+	// приглашения мастеров на своё объявление
+	protected.Get("/{adID}/invitations", AdInvitationsHandler(db, logger))                      // GET /my-ads/123/invitations
+	protected.With(createLimit).Post("/{adID}/invitations", InviteWorkersHandler(db, logger))   // POST /my-ads/123/invitations - пригласить
+	protected.Delete("/{adID}/invitations/{invitationID}", RevokeInvitationHandler(db, logger)) // DELETE /my-ads/123/invitations/4 - отозвать
 
 	// МАСТЕРА (управление откликами)
-	master.Use(middleware.AuthMiddleware(logger))
-	master.Get("/", MasterResponsesHandler(db, logger))                // GET /responses - мои отклики
-	master.Post("/", MasterResponsesHandler(db, logger))               // POST /responses - создать отклик
-	master.Delete("/{responseID}", MasterResponsesHandler(db, logger)) // DELETE /responses/123 - удалить отклик
-
-	r.Mount("/ads", public)       // /ads → публичные объявления (для всех)
-	r.Mount("/my-ads", protected) // /my-ads → личный кабинет клиента
-	r.Mount("/responses", master) // /responses → отклики мастера
+	master.Use(middleware.AuthMiddleware(db, logger))
+	master.Use(middleware.Idempotency(db, logger))
+	master.Get("/", MasterResponsesHandler(db, logger))                    // GET /responses - мои отклики
+	master.Get("/recommended-ads", RecommendedAdsHandler(db, logger))      // GET /responses/recommended-ads - подходящие объявления
+	master.With(createLimit).Post("/", MasterResponsesHandler(db, logger)) // POST /responses - создать отклик
+	master.Delete("/{responseID}", MasterResponsesHandler(db, logger))     // DELETE /responses/123 - удалить отклик
+
+	// торг по отклику: доступен мастеру и владельцу объявления
+	master.Get("/{responseID}/offers", OffersHandler(db, logger))                         // GET /responses/123/offers - история предложений
+	master.With(createLimit).Post("/{responseID}/offers", CreateOfferHandler(db, logger)) // POST /responses/123/offers - новое предложение
+	master.Patch("/{responseID}/offers/{offerID}/accept", AcceptOfferHandler(db, logger)) // PATCH /responses/123/offers/5/accept
+
+	// ПРИГЛАШЕНИЯ (мастер отвечает на приглашения клиентов)
+	invitations.Use(middleware.AuthMiddleware(db, logger))
+	invitations.Use(middleware.Idempotency(db, logger))
+	invitations.Get("/", MyInvitationsHandler(db, logger))                                             // GET /invitations - мои приглашения
+	invitations.With(createLimit).Patch("/{invitationID}/accept", AcceptInvitationHandler(db, logger)) // PATCH /invitations/4/accept - откликнуться
+	invitations.Patch("/{invitationID}/decline", DeclineInvitationHandler(db, logger))                 // PATCH /invitations/4/decline - отказаться
+
+	r.Mount("/ads", public)              // /ads → публичные объявления (для всех)
+	r.Mount("/my-ads", protected)        // /my-ads → личный кабинет клиента
+	r.Mount("/responses", master)        // /responses → отклики мастера
+	r.Mount("/invitations", invitations) // /invitations → приглашения мастера
 }
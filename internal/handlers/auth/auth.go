@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"go-api/internal/auth"
+	"go-api/internal/metrics"
+	"go-api/internal/middleware"
+	"go-api/internal/ratelimit"
+	"go-api/internal/tracing"
 
 	// "go-api/internal/models"
 	"go-api/internal/storage"
@@ -15,6 +19,7 @@ import (
 
 func LoginHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		if r.Method != http.MethodPost {
 			logger.Error("Неправильный метод",
 				"method", r.Method,
@@ -36,10 +41,31 @@ func LoginHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		// Прогрессивная блокировка по email: проверяем до bcrypt, чтобы перебор не нагружал CPU
+		if locked, err := ratelimit.LoginLocked(r.Context(), input.Email); err != nil {
+			logger.Error("login lockout check failed", "error", err)
+		} else if locked > 0 {
+			logger.Warn("login rejected: too many failed attempts", "email", input.Email)
+			metrics.RateLimited.WithLabelValues("login").Inc()
+			middleware.TooManyRequests(w, locked)
+			return
+		}
+
+		// неудачная попытка (в том числе с несуществующим email) приближает блокировку
+		loginFailed := func() {
+			lock, err := ratelimit.LoginFailed(r.Context(), input.Email)
+			if err != nil {
+				logger.Error("failed to record login failure", "error", err)
+			} else if lock > 0 {
+				logger.Warn("login locked after failed attempts", "email", input.Email, "lockout", lock.String())
+			}
+			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		}
+
 		user, err := storage.UserByEmail(db, input.Email)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+				loginFailed()
 			} else {
 				http.Error(w, "Database error", http.StatusInternalServerError)
 			}
@@ -48,7 +74,22 @@ func LoginHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 
 		if !auth.CheckPasswordHash(input.Password, user.PasswordHash) {
 			logger.Debug("Неверный пароль", "user", user)
-			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+			loginFailed()
+			return
+		}
+
+		if err := ratelimit.LoginSucceeded(r.Context(), input.Email); err != nil {
+			logger.Error("failed to reset login failures", "error", err)
+		}
+
+		suspension, err := storage.ActiveSuspension(db, user.ID, storage.SuspensionScopeLogin)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if suspension != nil {
+			logger.Warn("login rejected: account suspended", "user_id", user.ID, "suspension_id", suspension.ID)
+			middleware.SuspendedError(w, suspension)
 			return
 		}
 
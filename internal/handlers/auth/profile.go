@@ -3,8 +3,10 @@ package auth
 import (
 	"encoding/json"
 	"errors"
+	"go-api/internal/cache"
 	"go-api/internal/models"
 	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 
@@ -13,6 +15,7 @@ import (
 
 func ProfileHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		switch r.Method {
 		case http.MethodGet:
 			getProfile(db, logger)(w, r)
@@ -29,6 +32,7 @@ func ProfileHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 
 func getProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, _ := tracing.Scope(r, db, logger)
 		userID, ok := r.Context().Value("user_id").(uint)
 		if !ok {
 			http.Error(w, "User not authenticated", http.StatusUnauthorized)
@@ -44,12 +48,20 @@ func getProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 		// Загружаем Worker-представление через единый storage-слой (с категориями)
 		workerResp, _ := storage.WorkerByUserID(db, userID)
 
+		// Версия профиля - версия WorkerProfile (0, если его нет); её нужно передать в If-Match при PATCH
+		version, err := profileVersion(db, userID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
 		response := map[string]interface{}{
-			"id":    user.ID,
-			"email": user.Email,
-			"role":  user.Role.RoleName,
-			"name":  user.Name,
-			"phone": user.Phone,
+			"id":      user.ID,
+			"email":   user.Email,
+			"role":    user.Role.RoleName,
+			"name":    user.Name,
+			"phone":   user.Phone,
+			"version": version,
 		}
 
 		if workerResp != nil && workerResp.HaveWorkerProfile {
@@ -58,21 +70,33 @@ func getProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 				"specialization": workerResp.Categories,
 				"experience":     workerResp.ExpYears,
 				"description":    workerResp.Description,
-				"is_busy":        workerResp.IsBusy,
+				"is_busy":        workerResp.IsBusy, // вычисляется по расписанию и броням (GET /handyman/availability)
 				"location":       workerResp.Location,
 				"schedule":       workerResp.Schedule,
+				"timezone":       workerResp.Timezone,
 			}
 		} else {
 			response["have_worker_profile"] = false
 		}
 
+		w.Header().Set("ETag", cache.VersionETag(version))
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
+// profileVersion - версия профиля мастера пользователя (0, если профиля нет)
+func profileVersion(db *gorm.DB, userID uint) (uint, error) {
+	var wp models.WorkerProfile
+	if err := db.Select("id, version").Where("user_id = ?", userID).Limit(1).Find(&wp).Error; err != nil {
+		return 0, err
+	}
+	return wp.Version, nil
+}
+
 // самый важный момент - решить проблему, если сначала регаешься как юзер, а потом как рабочий
 func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, _ := tracing.Scope(r, db, logger)
 		userID, ok := r.Context().Value("user_id").(uint)
 		if !ok {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -87,19 +111,27 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			// WorkerProfile поля
 			ExpYears    *int    `json:"exp_years,omitempty"`
 			Description *string `json:"description,omitempty"`
-			IsBusy      *bool   `json:"is_busy,omitempty"`
 			Location    *string `json:"location,omitempty"`
 			Schedule    *string `json:"schedule,omitempty"`
 
-			// Категории по НАЗВАНИЯМ (полная замена списка)
+			// Категории по ID или слагам (полная замена списка).
+			// category_names - по названиям, оставлено для старых клиентов
+			Categories    []string `json:"categories,omitempty"`
 			CategoryNames []string `json:"category_names,omitempty"`
 		}
 
+		// Оптимистичная блокировка: изменение только той версии, которую клиент видел в GET /profile
+		version, anyVersion, ok := cache.RequireIfMatch(w, r)
+		if !ok {
+			return
+		}
+
 		var input ProfileInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
+		categoryRefs := append(input.Categories, input.CategoryNames...)
 
 		tx := db.Begin()
 		defer func() {
@@ -108,6 +140,18 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			}
 		}()
 
+		current, err := profileVersion(tx, userID)
+		if err != nil {
+			tx.Rollback()
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if !anyVersion && current != version {
+			tx.Rollback()
+			cache.PreconditionFailed(w, current)
+			return
+		}
+
 		userUpdates := map[string]interface{}{}
 		if input.Name != nil {
 			userUpdates["name"] = *input.Name
@@ -144,9 +188,6 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 		if input.Description != nil {
 			workerUpdates["description"] = *input.Description
 		}
-		if input.IsBusy != nil {
-			workerUpdates["is_busy"] = *input.IsBusy
-		}
 		if input.Location != nil {
 			workerUpdates["location"] = *input.Location
 		}
@@ -154,8 +195,8 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			workerUpdates["schedule"] = *input.Schedule
 		}
 
-		// Обновление категорий работника (полная замена списка по НАЗВАНИЯМ)
-		if len(input.CategoryNames) > 0 {
+		// Обновление категорий работника (полная замена списка)
+		if len(categoryRefs) > 0 {
 			// Гарантируем наличие WorkerProfile (для старых пользователей)
 			var wp models.WorkerProfile
 			if err := tx.FirstOrCreate(&wp, models.WorkerProfile{UserID: userID}).Error; err != nil {
@@ -171,17 +212,17 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 				return
 			}
 
-			// Вставляем новые связи по именам категорий
-			for _, name := range input.CategoryNames {
-				var category models.Category
-				if err := tx.Where("name ILIKE ?", name).First(&category).Error; err != nil {
+			// Вставляем новые связи
+			for _, ref := range categoryRefs {
+				category, err := storage.CategoryByRef(tx, ref)
+				if err != nil {
 					tx.Rollback()
 					http.Error(w, "Category not found", http.StatusBadRequest)
 					return
 				}
 
 				wc := models.WorkerCategory{WorkerID: userID, CategoryID: category.ID}
-				if err := tx.Create(&wc).Error; err != nil {
+				if err := tx.Where(&wc).FirstOrCreate(&wc).Error; err != nil {
 					tx.Rollback()
 					http.Error(w, "Database error", http.StatusInternalServerError)
 					return
@@ -190,23 +231,37 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 		}
 
 		// Если пришли данные для воркера или категорий, помечаем профиль как активный
-		if len(workerUpdates) > 0 || len(input.CategoryNames) > 0 {
+		if len(workerUpdates) > 0 || len(categoryRefs) > 0 {
 			workerUpdates["have_worker_profile"] = true
 		}
 
-		if len(userUpdates) == 0 && len(workerUpdates) == 0 && len(input.CategoryNames) == 0 {
+		if len(userUpdates) == 0 && len(workerUpdates) == 0 && len(categoryRefs) == 0 {
 			tx.Rollback()
 			http.Error(w, "Database error, no fields", http.StatusInternalServerError)
 			return
 		}
 
-		if len(workerUpdates) > 0 {
-			result := tx.Model(&models.WorkerProfile{}).Where("user_id = ?", userID).Updates(workerUpdates)
-			if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				tx.Rollback()
-				http.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
+		// Любое изменение профиля увеличивает версию. Условие на версию отсекает изменение
+		// (например, модерацию), случившееся после проверки If-Match
+		versioned := map[string]interface{}{"version": storage.NextVersion()}
+		for k, v := range workerUpdates {
+			versioned[k] = v
+		}
+		query := tx.Model(&models.WorkerProfile{}).Where("user_id = ?", userID)
+		if current != 0 {
+			query = query.Where("version = ?", current)
+		}
+		result := query.Updates(versioned)
+		if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if result.RowsAffected == 0 && current != 0 {
+			tx.Rollback()
+			latest, _ := profileVersion(db, userID)
+			cache.PreconditionFailed(w, latest)
+			return
 		}
 
 		if err := tx.Commit().Error; err != nil {
@@ -219,8 +274,14 @@ func editProfile(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			http.Error(w, "User not found", http.StatusNotFound)
 			return
 		}
+		var newVersion uint
+		if updatedUser.WorkerProfile != nil {
+			newVersion = updatedUser.WorkerProfile.Version
+		}
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", cache.VersionETag(newVersion))
 		json.NewEncoder(w).Encode(map[string]interface{}{
+			"version":        newVersion,
 			"id":             updatedUser.ID,
 			"email":          updatedUser.Email,
 			"name":           updatedUser.Name,
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"go-api/internal/auth"
 	"go-api/internal/models"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 
@@ -12,6 +13,7 @@ import (
 
 func RegisterHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		if r.Method != http.MethodPost {
 			logger.Error("Неправильный метод",
 				"method", r.Method,
@@ -82,7 +84,6 @@ func RegisterHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 		// и сразу создаём связанный WorkerProfile для любого пользователя (user_id == worker_id)
 		workerProfile := models.WorkerProfile{
 			UserID:            user.ID,
-			IsBusy:            false,
 			HaveWorkerProfile: false, // пока профиль не заполнен
 		}
 		if err := tx.Create(&workerProfile).Error; err != nil {
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"go-api/internal/middleware"
+	"go-api/internal/ratelimit"
 	"log/slog"
 
 	"github.com/go-chi/chi/v5"
@@ -10,12 +11,13 @@ import (
 
 func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/login", LoginHandler(db, logger))
-		r.Post("/register", RegisterHandler(db, logger))
+		r.Use(middleware.RateLimit(ratelimit.GroupAuth, logger))
+		r.Post("/login", LoginHandler(db, logger)) // вход можно повторять и так, токены не сохраняются
+		r.With(middleware.Idempotency(db, logger)).Post("/register", RegisterHandler(db, logger))
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(logger))
+		r.Use(middleware.AuthMiddleware(db, logger))
 		r.Get("/profile", ProfileHandler(db, logger))
 		r.Patch("/profile", ProfileHandler(db, logger))
 	})
@@ -0,0 +1,56 @@
+package docs
+
+import (
+	_ "embed"
+	"go-api/internal/cache"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Спецификация OpenAPI 3 всех маршрутов API. При добавлении маршрута в SetupRoutes
+// любого пакета handlers его нужно описать здесь, иначе упадёт тест cmd/api/router_test.go
+//
+//go:embed openapi.json
+var spec []byte
+
+// Spec возвращает спецификацию OpenAPI в JSON
+func Spec() []byte {
+	return spec
+}
+
+// Swagger UI с CDN: статика не хранится в репозитории
+const uiPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>Handyman API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func SetupRoutes(logger *slog.Logger, r chi.Router) {
+	specEntry := cache.NewEntry(spec, time.Time{})
+
+	r.Get("/api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		cache.Write(w, r, specEntry, cache.ControlRevalidate)
+	})
+
+	r.Get("/api/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := w.Write([]byte(uiPage)); err != nil {
+			logger.Debug("failed to write docs page", "error", err)
+		}
+	})
+}
@@ -2,34 +2,157 @@ package info
 
 import (
 	"encoding/json"
+	"fmt"
+	"go-api/internal/cache"
 	"go-api/internal/models"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+type categoryNode struct {
+	ID        uint           `json:"id"`
+	Slug      string         `json:"slug"`
+	Name      string         `json:"name"`
+	Icon      string         `json:"icon,omitempty"`
+	SortOrder int            `json:"sort_order"`
+	ParentID  *uint          `json:"parent_id"`
+	Children  []categoryNode `json:"children,omitempty"`
+}
+
+// CategoriesHandler - дерево категорий (?lang=en - названия на языке, ?flat=true - плоский список).
+// Ответ кэшируется в памяти до изменения категорий через админку
 func CategoriesHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
-		var categories []models.Category
+		// язык без переводов отдаётся как язык по умолчанию: один вариант ответа вместо записи на каждый тег
+		locale, err := translatedLocale(db, requestLocale(r))
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			logger.Error("Ошибка загрузки языков категорий из бд", "error", err)
+			return
+		}
+		flat := r.URL.Query().Get("flat") == "true"
+		key := fmt.Sprintf("%s?lang=%s&flat=%t", cache.Categories, locale, flat)
+
+		entry, err := cache.Load(key, func() ([]byte, error) {
+			var categories []models.Category
+
+			if err := db.Preload("Translations").Order("sort_order, name").Find(&categories).Error; err != nil {
+				return nil, err
+			}
+
+			nodes := make([]categoryNode, len(categories))
+			for i, cat := range categories {
+				nodes[i] = categoryNode{
+					ID:        cat.ID,
+					Slug:      cat.Slug,
+					Name:      localizedName(cat, locale),
+					Icon:      cat.Icon,
+					SortOrder: cat.SortOrder,
+					ParentID:  cat.ParentID,
+				}
+			}
 
-		if err := db.Select("id, name").Find(&categories).Error; err != nil {
+			if flat {
+				return json.Marshal(nodes)
+			}
+			return json.Marshal(buildTree(nodes))
+		})
+		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			logger.Error("Ошибка парсинга категорий из бд", "error", err)
 			return
 		}
 
-		type CategoryName struct {
-			Name string `json:"name"`
-			ID   uint   `json:"id"`
+		w.Header().Add("Vary", "Accept-Language")
+		cache.Write(w, r, entry, cache.ControlReference)
+	}
+}
+
+// buildTree собирает дерево; категории с удалённым родителем становятся корневыми
+func buildTree(nodes []categoryNode) []categoryNode {
+	byParent := map[uint][]categoryNode{}
+	exists := make(map[uint]bool, len(nodes))
+	for _, n := range nodes {
+		exists[n.ID] = true
+	}
+
+	var roots []categoryNode
+	for _, n := range nodes {
+		if n.ParentID != nil && exists[*n.ParentID] {
+			byParent[*n.ParentID] = append(byParent[*n.ParentID], n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	var attach func(list []categoryNode) []categoryNode
+	attach = func(list []categoryNode) []categoryNode {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].SortOrder < list[j].SortOrder })
+		for i := range list {
+			if children, ok := byParent[list[i].ID]; ok {
+				list[i].Children = attach(children)
+			}
 		}
+		return list
+	}
+
+	if roots == nil {
+		return []categoryNode{}
+	}
+	return attach(roots)
+}
+
+// requestLocale - язык из ?lang= или первого тега Accept-Language (en-US → en)
+func requestLocale(r *http.Request) string {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = strings.Split(r.Header.Get("Accept-Language"), ",")[0]
+	}
+	lang = strings.TrimSpace(strings.Split(lang, ";")[0])
+	return strings.ToLower(strings.Split(lang, "-")[0])
+}
+
+// translatedLocale возвращает locale, если для него есть переводы категорий, иначе "" (названия по умолчанию).
+// Список языков кэшируется вместе с ответами и сбрасывается той же инвалидацией
+func translatedLocale(db *gorm.DB, locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+	entry, err := cache.Load(cache.Categories+"?locales", func() ([]byte, error) {
+		var locales []string
+		if err := db.Model(&models.CategoryTranslation{}).Distinct().Pluck("locale", &locales).Error; err != nil {
+			return nil, err
+		}
+		return json.Marshal(locales)
+	})
+	if err != nil {
+		return "", err
+	}
+	var locales []string
+	if err := json.Unmarshal(entry.Body, &locales); err != nil {
+		return "", err
+	}
+	for _, l := range locales {
+		if l == locale {
+			return locale, nil
+		}
+	}
+	return "", nil
+}
 
-		names := make([]CategoryName, len(categories))
-		for i, cat := range categories {
-			names[i] = CategoryName{ID: cat.ID, Name: cat.Name}
+func localizedName(c models.Category, locale string) string {
+	for _, t := range c.Translations {
+		if t.Locale == locale {
+			return t.Name
 		}
-		json.NewEncoder(w).Encode(names)
 	}
+	return c.Name
 }
@@ -2,7 +2,9 @@ package info
 
 import (
 	"encoding/json"
+	"go-api/internal/cache"
 	"go-api/internal/models"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 
@@ -11,26 +13,34 @@ import (
 
 func PriceUnitsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
-		var price_units []models.PriceUnit
+		entry, err := cache.Load(cache.PriceUnits, func() ([]byte, error) {
+			var price_units []models.PriceUnit
 
-		if err := db.Select("id, name").Find(&price_units).Error; err != nil {
+			if err := db.Select("id, name").Find(&price_units).Error; err != nil {
+				return nil, err
+			}
+
+			type PriceUnit struct {
+				Name string `json:"name"`
+				ID   uint   `json:"id"`
+			}
+
+			result := make([]PriceUnit, len(price_units))
+			for i, p := range price_units {
+				result[i] = PriceUnit{ID: p.ID, Name: p.Name}
+			}
+
+			return json.Marshal(result)
+		})
+		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			logger.Error("Ошибка парсинга цен из бд", "error", err)
 			return
 		}
 
-		type PriceUnit struct {
-			Name string `json:"name"`
-			ID   uint   `json:"id"`
-		}
-
-		result := make([]PriceUnit, len(price_units))
-		for i, p := range price_units {
-			result[i] = PriceUnit{ID: p.ID, Name: p.Name}
-		}
-
-		json.NewEncoder(w).Encode(result)
+		cache.Write(w, r, entry, cache.ControlReference)
 	}
 }
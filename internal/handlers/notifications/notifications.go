@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"encoding/json"
+	"go-api/internal/models"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// MyNotificationsHandler - уведомления текущего пользователя, новые сверху (?unread=true - только непрочитанные)
+func MyNotificationsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		query := db.Model(&models.Notification{}).Where("user_id = ?", userID)
+		if r.URL.Query().Get("unread") == "true" {
+			query = query.Where("read_at IS NULL")
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var unread int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+		var notifications []models.Notification
+		if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+			logger.Error("failed to get notifications", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"notifications": notifications,
+			"total":         total,
+			"unread":        unread,
+			"limit":         limit,
+			"offset":        offset,
+		})
+	}
+}
+
+// MarkReadHandler - отметить уведомление прочитанным
+func MarkReadHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseUint(chi.URLParam(r, "notificationID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid notification id"}`, http.StatusBadRequest)
+			return
+		}
+
+		var notification models.Notification
+		if err := db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+			http.Error(w, `{"error": "notification not found"}`, http.StatusNotFound)
+			return
+		}
+
+		if notification.ReadAt == nil {
+			now := time.Now()
+			if err := db.Model(&notification).Update("read_at", now).Error; err != nil {
+				logger.Error("failed to mark notification read", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+				return
+			}
+			notification.ReadAt = &now
+		}
+
+		json.NewEncoder(w).Encode(notification)
+	}
+}
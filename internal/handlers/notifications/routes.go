@@ -0,0 +1,17 @@
+package notifications
+
+import (
+	"go-api/internal/middleware"
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(db, logger))
+		r.Get("/", MyNotificationsHandler(db, logger))                 // GET /notifications - мои уведомления
+		r.Patch("/{notificationID}/read", MarkReadHandler(db, logger)) // PATCH /notifications/1/read - прочитано
+	})
+}
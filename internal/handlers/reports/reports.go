@@ -0,0 +1,206 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Допустимые объекты жалоб
+var targetTypes = map[string]bool{
+	"ad":       true,
+	"worker":   true,
+	"response": true,
+}
+
+// Допустимые причины жалоб
+var reasons = map[string]bool{
+	"fraud":         true,
+	"spam":          true,
+	"abuse":         true,
+	"inappropriate": true,
+	"other":         true,
+}
+
+// CreateReportHandler - пожаловаться на объявление, мастера или отклик
+func CreateReportHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		type CreateReportRequest struct {
+			TargetType string `json:"target_type"`
+			TargetID   uint   `json:"target_id"`
+			Reason     string `json:"reason"`
+			Comment    string `json:"comment"`
+		}
+
+		var req CreateReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		if !targetTypes[req.TargetType] || req.TargetID == 0 {
+			http.Error(w, `{"error": "target_type (ad, worker, response) and target_id are required"}`, http.StatusBadRequest)
+			return
+		}
+		if !reasons[req.Reason] {
+			http.Error(w, `{"error": "reason must be one of: fraud, spam, abuse, inappropriate, other"}`, http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(req.Comment) > 1000 {
+			http.Error(w, `{"error": "comment is too long"}`, http.StatusBadRequest)
+			return
+		}
+
+		// Проверяем объект жалобы и что он не принадлежит автору жалобы
+		ownerID, err := targetOwner(db, req.TargetType, req.TargetID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, `{"error": "report target not found"}`, http.StatusNotFound)
+			} else {
+				logger.Error("failed to find report target", "error", err)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			}
+			return
+		}
+		if ownerID == userID {
+			http.Error(w, `{"error": "cannot report yourself"}`, http.StatusBadRequest)
+			return
+		}
+
+		// Повторная жалоба на тот же объект не создаёт новую запись
+		var existing models.Report
+		findExisting := func() error {
+			return db.Where("reporter_id = ? AND target_type = ? AND target_id = ?", userID, req.TargetType, req.TargetID).
+				First(&existing).Error
+		}
+		alreadyReported := func() {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "already reported",
+				"report":  existing,
+			})
+		}
+		err = findExisting()
+		if err == nil {
+			alreadyReported()
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("failed to check existing report", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		report := models.Report{
+			ReporterID: userID,
+			TargetType: req.TargetType,
+			TargetID:   req.TargetID,
+			Reason:     req.Reason,
+			Comment:    req.Comment,
+			Status:     "open",
+		}
+
+		if err := db.Create(&report).Error; err != nil {
+			// одновременный запрос успел создать ту же жалобу
+			if storage.IsUniqueViolation(err) && findExisting() == nil {
+				alreadyReported()
+				return
+			}
+			logger.Error("failed to create report", "error", err)
+			http.Error(w, `{"error": "failed to create report"}`, http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("report created", "report_id", report.ID, "target_type", report.TargetType, "target_id", report.TargetID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// MyReportsHandler - список жалоб текущего пользователя
+func MyReportsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value("user_id").(uint)
+		if !ok {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		limit := 10
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			limit, _ = strconv.Atoi(l)
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			offset, _ = strconv.Atoi(o)
+		}
+
+		var total int64
+		db.Model(&models.Report{}).Where("reporter_id = ?", userID).Count(&total)
+
+		var reports []models.Report
+		if err := db.Where("reporter_id = ?", userID).
+			Order("created_at DESC").
+			Limit(limit).
+			Offset(offset).
+			Find(&reports).Error; err != nil {
+			logger.Error("failed to get my reports", "error", err)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"reports": reports,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		})
+	}
+}
+
+// targetOwner возвращает user_id владельца объекта жалобы
+func targetOwner(db *gorm.DB, targetType string, targetID uint) (uint, error) {
+	switch targetType {
+	case "ad":
+		var ad models.Ad
+		if err := db.Select("id, user_id").First(&ad, targetID).Error; err != nil {
+			return 0, err
+		}
+		return ad.UserID, nil
+	case "worker":
+		// /handyman/{id} - это user_id мастера
+		var wp models.WorkerProfile
+		if err := db.Select("id, user_id").
+			Where("user_id = ? AND have_worker_profile = ?", targetID, true).
+			First(&wp).Error; err != nil {
+			return 0, err
+		}
+		return wp.UserID, nil
+	case "response":
+		var resp models.Response
+		if err := db.Select("id, worker_id").First(&resp, targetID).Error; err != nil {
+			return 0, err
+		}
+		return resp.WorkerID, nil
+	}
+	return 0, gorm.ErrRecordNotFound
+}
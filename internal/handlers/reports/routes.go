@@ -0,0 +1,20 @@
+package reports
+
+import (
+	"go-api/internal/middleware"
+	"go-api/internal/ratelimit"
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
+	r.Route("/reports", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(db, logger))
+		r.Use(middleware.Idempotency(db, logger))
+		r.Get("/", MyReportsHandler(db, logger)) // GET /reports - мои жалобы
+		r.With(middleware.RateLimit(ratelimit.GroupCreate, logger)).
+			Post("/", CreateReportHandler(db, logger)) // POST /reports - пожаловаться
+	})
+}
@@ -1,16 +1,46 @@
 package sys
 
 import (
+	"context"
+	"encoding/json"
+	"go-api/internal/metrics"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
-	r.Get("/api/health", func(w http.ResponseWriter, r *http.Request) {
+func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router, pingTimeout time.Duration) {
+	// Процесс жив (БД не проверяется: её недоступность не лечится перезапуском)
+	live := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
+	}
+	r.Get("/livez", live)
+	r.Get("/api/health", live) // старый адрес, оставлен для совместимости
+
+	// Готовность принимать трафик: БД отвечает на ping за pingTimeout
+	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		sqlDB, err := db.DB()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+			defer cancel()
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			// причина только в логе: эндпоинт открытый, текст ошибки может раскрыть адрес и пользователя БД
+			logger.Warn("readiness check failed", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "unavailable"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok", "db": "ok"})
 	})
+
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
 }
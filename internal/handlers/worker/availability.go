@@ -0,0 +1,386 @@
+package worker
+
+import (
+	"encoding/json"
+	"go-api/internal/availability"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout        = "2006-01-02"
+	maxSlotsRangeDays = 31  // самый длинный период для GET /handyman/{id}/slots
+	maxWeeklyItems    = 50  // интервалов в недельном расписании
+	maxExceptionDays  = 366 // самое длинное исключение
+)
+
+// currentWorker - мастер текущего пользователя, иначе 401/403
+func currentWorker(db *gorm.DB, w http.ResponseWriter, r *http.Request) (*storage.WorkerResponse, bool) {
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	worker, err := storage.WorkerByUserID(db, userID)
+	if err != nil || worker == nil || worker.ID == 0 {
+		http.Error(w, `{"error": "worker not found"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return worker, true
+}
+
+// MyAvailabilityHandler - своё недельное расписание и предстоящие исключения
+func MyAvailabilityHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		worker, ok := currentWorker(db, w, r)
+		if !ok {
+			return
+		}
+		writeAvailability(db, logger, w, worker.ID)
+	}
+}
+
+func writeAvailability(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, workerID uint) {
+	loc, err := storage.WorkerLocation(db, workerID)
+	if err != nil {
+		logger.Error("failed to get worker timezone", "error", err, "worker_id", workerID)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	weekly, err := storage.WeeklyAvailability(db, workerID)
+	if err != nil {
+		logger.Error("failed to get weekly availability", "error", err, "worker_id", workerID)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	exceptions, err := storage.AvailabilityExceptions(db, workerID, time.Now().In(loc), time.Time{})
+	if err != nil {
+		logger.Error("failed to get availability exceptions", "error", err, "worker_id", workerID)
+		http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"timezone":   loc.String(),
+		"weekly":     weekly,
+		"exceptions": exceptions,
+	})
+}
+
+// UpdateAvailabilityHandler - заменить недельное расписание (полная замена списка интервалов)
+func UpdateAvailabilityHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		worker, ok := currentWorker(db, w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Timezone string                      `json:"timezone"` // пусто - не менять
+			Weekly   []models.WorkerAvailability `json:"weekly"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		if req.Timezone == "" {
+			req.Timezone = worker.Timezone
+		}
+		if _, err := time.LoadLocation(req.Timezone); err != nil || req.Timezone == "Local" {
+			http.Error(w, `{"error": "unknown timezone"}`, http.StatusBadRequest)
+			return
+		}
+		if msg := validateWeekly(req.Weekly); msg != "" {
+			http.Error(w, `{"error": "`+msg+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := storage.ReplaceWeeklyAvailability(db, worker.ID, req.Timezone, req.Weekly); err != nil {
+			logger.Error("failed to update weekly availability", "error", err, "worker_id", worker.ID)
+			http.Error(w, `{"error": "failed to update availability"}`, http.StatusInternalServerError)
+			return
+		}
+		logger.Info("weekly availability updated", "worker_id", worker.ID, "intervals", len(req.Weekly))
+
+		writeAvailability(db, logger, w, worker.ID)
+	}
+}
+
+// validateWeekly - текст ошибки или пустая строка. Интервалы одного дня не должны пересекаться
+func validateWeekly(weekly []models.WorkerAvailability) string {
+	if len(weekly) > maxWeeklyItems {
+		return "too many weekly intervals"
+	}
+	type span struct{ start, end int }
+	byDay := map[int][]span{}
+	for _, item := range weekly {
+		if item.Weekday < 1 || item.Weekday > 7 {
+			return "weekday must be from 1 (monday) to 7 (sunday)"
+		}
+		start, ok1 := availability.ParseClock(item.Start)
+		end, ok2 := availability.ParseClock(item.End)
+		if !ok1 || !ok2 {
+			return "start and end must be HH:MM"
+		}
+		if end <= start {
+			return "end must be after start"
+		}
+		byDay[item.Weekday] = append(byDay[item.Weekday], span{start, end})
+	}
+	for _, spans := range byDay {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+		for i := 1; i < len(spans); i++ {
+			if spans[i].start < spans[i-1].end {
+				return "weekly intervals of the same day must not overlap"
+			}
+		}
+	}
+	return ""
+}
+
+// AddExceptionHandler - добавить исключение из расписания (отпуск, занятые дни)
+func AddExceptionHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		worker, ok := currentWorker(db, w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			DateFrom string `json:"date_from"`
+			DateTo   string `json:"date_to"` // пусто - один день
+			Reason   string `json:"reason"`
+			Note     string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.DateTo == "" {
+			req.DateTo = req.DateFrom
+		}
+		dateFrom, err1 := time.Parse(dateLayout, req.DateFrom)
+		dateTo, err2 := time.Parse(dateLayout, req.DateTo)
+		if err1 != nil || err2 != nil {
+			http.Error(w, `{"error": "date_from and date_to must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		if dateTo.Before(dateFrom) {
+			http.Error(w, `{"error": "date_to must not be before date_from"}`, http.StatusBadRequest)
+			return
+		}
+		if dateTo.Sub(dateFrom) >= maxExceptionDays*24*time.Hour {
+			http.Error(w, `{"error": "exception is too long"}`, http.StatusBadRequest)
+			return
+		}
+		loc, err := time.LoadLocation(worker.Timezone)
+		if err != nil {
+			logger.Error("invalid worker timezone", "error", err, "worker_id", worker.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().In(loc)
+		if dateTo.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)) {
+			http.Error(w, `{"error": "date_to must not be in the past"}`, http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = models.ExceptionVacation
+		}
+		if req.Reason != models.ExceptionVacation && req.Reason != models.ExceptionBooked && req.Reason != models.ExceptionOther {
+			http.Error(w, `{"error": "reason must be vacation, booked or other"}`, http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(req.Note) > 255 {
+			http.Error(w, `{"error": "note must be at most 255 characters"}`, http.StatusBadRequest)
+			return
+		}
+
+		exception := models.AvailabilityException{
+			WorkerID: worker.ID,
+			DateFrom: dateFrom,
+			DateTo:   dateTo,
+			Reason:   req.Reason,
+			Note:     req.Note,
+		}
+		if err := db.Create(&exception).Error; err != nil {
+			logger.Error("failed to create availability exception", "error", err, "worker_id", worker.ID)
+			http.Error(w, `{"error": "failed to create exception"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(exception)
+	}
+}
+
+// DeleteExceptionHandler - удалить своё исключение из расписания
+func DeleteExceptionHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		worker, ok := currentWorker(db, w, r)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.ParseUint(chi.URLParam(r, "exceptionID"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid exception id"}`, http.StatusBadRequest)
+			return
+		}
+
+		result := db.Where("id = ? AND worker_id = ?", id, worker.ID).Delete(&models.AvailabilityException{})
+		if result.Error != nil {
+			logger.Error("failed to delete availability exception", "error", result.Error, "worker_id", worker.ID)
+			http.Error(w, `{"error": "failed to delete exception"}`, http.StatusInternalServerError)
+			return
+		}
+		if result.RowsAffected == 0 {
+			http.Error(w, `{"error": "exception not found"}`, http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "exception deleted successfully"})
+	}
+}
+
+// MyBookingsHandler - предстоящие брони мастера (?from=YYYY-MM-DD, по умолчанию с текущего момента)
+func MyBookingsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		worker, ok := currentWorker(db, w, r)
+		if !ok {
+			return
+		}
+
+		from := time.Now()
+		if v := r.URL.Query().Get("from"); v != "" {
+			date, err := time.Parse(dateLayout, v)
+			if err != nil {
+				http.Error(w, `{"error": "from must be YYYY-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			from = date
+		}
+
+		var bookings []models.Booking
+		if err := db.Where("worker_id = ? AND status = ? AND ends_at > ?", worker.ID, models.BookingActive, from).
+			Order("starts_at").Find(&bookings).Error; err != nil {
+			logger.Error("failed to get bookings", "error", err, "worker_id", worker.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"bookings": bookings})
+	}
+}
+
+// FreeSlotsHandler - свободное время мастера (публично): ?from=&to= (YYYY-MM-DD по часовому поясу
+// мастера, по умолчанию ближайшие 7 дней), ?duration= (минуты) - только окна не короче
+func FreeSlotsHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "invalid worker id"}`, http.StatusBadRequest)
+			return
+		}
+		worker, err := storage.WorkerByID(db, uint(id))
+		if err != nil || worker == nil {
+			http.Error(w, `{"error": "worker not found"}`, http.StatusNotFound)
+			return
+		}
+		loc, err := time.LoadLocation(worker.Timezone)
+		if err != nil {
+			logger.Error("invalid worker timezone", "error", err, "worker_id", worker.ID)
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		fromDate, toDate := today, today.AddDate(0, 0, 6)
+		if v := r.URL.Query().Get("from"); v != "" {
+			if fromDate, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+				http.Error(w, `{"error": "from must be YYYY-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			toDate = fromDate.AddDate(0, 0, 6)
+		}
+		if v := r.URL.Query().Get("to"); v != "" {
+			if toDate, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+				http.Error(w, `{"error": "to must be YYYY-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		if toDate.Before(fromDate) {
+			http.Error(w, `{"error": "to must not be before from"}`, http.StatusBadRequest)
+			return
+		}
+		if toDate.After(fromDate.AddDate(0, 0, maxSlotsRangeDays-1)) {
+			http.Error(w, `{"error": "period must be at most 31 days"}`, http.StatusBadRequest)
+			return
+		}
+		var minDuration time.Duration
+		if v := r.URL.Query().Get("duration"); v != "" {
+			minutes, err := strconv.Atoi(v)
+			if err != nil || minutes <= 0 {
+				http.Error(w, `{"error": "duration must be a positive number of minutes"}`, http.StatusBadRequest)
+				return
+			}
+			minDuration = time.Duration(minutes) * time.Minute
+		}
+
+		// прошедшее время не предлагается
+		start, end := fromDate, toDate.AddDate(0, 0, 1)
+		if start.Before(now) {
+			start = now.Truncate(time.Minute)
+		}
+		slots := []availability.Interval{}
+		if start.Before(end) {
+			free, _, err := storage.WorkerFreeSlots(db, worker.ID, start, end)
+			if err != nil {
+				logger.Error("failed to compute free slots", "error", err, "worker_id", worker.ID)
+				http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+				return
+			}
+			if found := availability.AtLeast(free, minDuration); found != nil {
+				slots = found
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"worker_id": worker.ID,
+			"timezone":  loc.String(),
+			"from":      fromDate.Format(dateLayout),
+			"to":        toDate.Format(dateLayout),
+			"slots":     slots,
+		})
+	}
+}
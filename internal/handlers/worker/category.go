@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go-api/internal/models"
 	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 
@@ -13,6 +14,7 @@ import (
 
 func CategoryHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		w.Header().Set("Content-Type", "application/json")
 
 		userID, ok := r.Context().Value("user_id").(uint)
@@ -46,7 +48,8 @@ func getMyCategories(w http.ResponseWriter, worker *storage.WorkerResponse) {
 
 func addMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, workerID uint) {
 	type CategoryReq struct {
-		CategoryNames []string `json:"category_names"`
+		Categories    []string `json:"categories"`     // ID или слаги
+		CategoryNames []string `json:"category_names"` // названия (устаревшее, для старых клиентов)
 	}
 	var req CategoryReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -70,12 +73,12 @@ func addMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseWrit
 		return
 	}
 
-	for _, name := range req.CategoryNames {
-		var category models.Category
-		if err := tx.Where("name ILIKE ?", name).First(&category).Error; err != nil {
+	for _, ref := range append(req.Categories, req.CategoryNames...) {
+		category, err := storage.CategoryByRef(tx, ref)
+		if err != nil {
 			tx.Rollback()
-			logger.Error("category not found", "name", name)
-			http.Error(w, fmt.Sprintf(`{"error": "category '%s' not found"}`, name), http.StatusBadRequest)
+			logger.Error("category not found", "ref", ref)
+			http.Error(w, fmt.Sprintf(`{"error": "category '%s' not found"}`, ref), http.StatusBadRequest)
 			return
 		}
 
@@ -89,6 +92,14 @@ func addMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseWrit
 		}
 	}
 
+	// Категории входят в карточку мастера: обновляем версию и Last-Modified профиля
+	if err := tx.Model(&models.WorkerProfile{}).Where("user_id = ?", workerID).Update("version", storage.NextVersion()).Error; err != nil {
+		tx.Rollback()
+		logger.Error("failed to touch worker profile", "error", err)
+		http.Error(w, `{"error": "failed to add category"}`, http.StatusInternalServerError)
+		return
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		logger.Error("failed to commit categories add tx", "error", err)
 		http.Error(w, `{"error": "failed to add category"}`, http.StatusInternalServerError)
@@ -111,7 +122,8 @@ func addMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseWrit
 
 func deleteMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseWriter, r *http.Request, workerID uint) {
 	type CategoryReq struct {
-		CategoryNames []string `json:"category_names"`
+		Categories    []string `json:"categories"`     // ID или слаги
+		CategoryNames []string `json:"category_names"` // названия (устаревшее, для старых клиентов)
 	}
 	var req CategoryReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -126,11 +138,11 @@ func deleteMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseW
 		}
 	}()
 
-	for _, name := range req.CategoryNames {
-		var category models.Category
-		if err := tx.Where("name ILIKE ?", name).First(&category).Error; err != nil {
+	for _, ref := range append(req.Categories, req.CategoryNames...) {
+		category, err := storage.CategoryByRef(tx, ref)
+		if err != nil {
 			tx.Rollback()
-			http.Error(w, fmt.Sprintf(`{"error": "category '%s' not found"}`, name), http.StatusBadRequest)
+			http.Error(w, fmt.Sprintf(`{"error": "category '%s' not found"}`, ref), http.StatusBadRequest)
 			return
 		}
 
@@ -144,6 +156,14 @@ func deleteMyCategoriesByName(db *gorm.DB, logger *slog.Logger, w http.ResponseW
 		}
 	}
 
+	// Категории входят в карточку мастера: обновляем версию и Last-Modified профиля
+	if err := tx.Model(&models.WorkerProfile{}).Where("user_id = ?", workerID).Update("version", storage.NextVersion()).Error; err != nil {
+		tx.Rollback()
+		logger.Error("failed to touch worker profile", "error", err)
+		http.Error(w, `{"error": "failed to delete category"}`, http.StatusInternalServerError)
+		return
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		logger.Error("failed to commit categories delete tx", "error", err)
 		http.Error(w, `{"error": "failed to delete category"}`, http.StatusInternalServerError)
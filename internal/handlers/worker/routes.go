@@ -14,15 +14,26 @@ func SetupRoutes(db *gorm.DB, logger *slog.Logger, r chi.Router) {
 	r.Route("/handyman", func(r chi.Router) {
 		r.Get("/", AllWorkersHandler(db, logger))
 		r.Get("/{id}", WorkerHandler(db, logger))
+		r.Get("/{id}/slots", FreeSlotsHandler(db, logger)) // GET /handyman/5/slots - свободное время мастера
 	})
 
 	// Маршруты для управления категориями конкретного мастера (требуют аутентификации)
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(logger))
+		r.Use(middleware.AuthMiddleware(db, logger))
+		r.Use(middleware.Idempotency(db, logger))
 		r.Route("/handyman/categories", func(r chi.Router) {
 			r.Method(http.MethodGet, "/", CategoryHandler(db, logger))
 			r.Method(http.MethodPost, "/", CategoryHandler(db, logger))
 			r.Method(http.MethodDelete, "/", CategoryHandler(db, logger))
 		})
+
+		// Расписание мастера: недельный шаблон, исключения и брони
+		r.Route("/handyman/availability", func(r chi.Router) {
+			r.Get("/", MyAvailabilityHandler(db, logger))                             // GET /handyman/availability
+			r.Put("/", UpdateAvailabilityHandler(db, logger))                         // PUT /handyman/availability - заменить шаблон
+			r.Post("/exceptions", AddExceptionHandler(db, logger))                    // POST /handyman/availability/exceptions
+			r.Delete("/exceptions/{exceptionID}", DeleteExceptionHandler(db, logger)) // DELETE /handyman/availability/exceptions/1
+		})
+		r.Get("/handyman/bookings", MyBookingsHandler(db, logger)) // GET /handyman/bookings - мои брони
 	})
 }
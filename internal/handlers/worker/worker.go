@@ -3,10 +3,13 @@ package worker
 import (
 	"encoding/json"
 	"errors"
+	"go-api/internal/cache"
 	"go-api/internal/storage"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
@@ -14,6 +17,7 @@ import (
 
 func AllWorkersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		limit := 10
 		offset := 0
 		if l := r.URL.Query().Get("limit"); l != "" {
@@ -46,15 +50,17 @@ func AllWorkersHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 
 func WorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		db, logger := tracing.Scope(r, db, logger)
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
 			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
 		}
 
 		worker, err := storage.WorkerByID(db, uint(id))
 		if err != nil || worker == nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
 				http.Error(w, "Worker not found", http.StatusNotFound)
 			} else {
 				http.Error(w, "Database error", http.StatusInternalServerError)
@@ -62,7 +68,12 @@ func WorkerHandler(db *gorm.DB, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		// в карточку входят категории и занятость по броням, которых нет в worker.UpdatedAt:
+		// без Last-Modified клиент перепроверяет карточку только по ETag от содержимого
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(worker)
+		if err := cache.WriteJSON(w, r, worker, time.Time{}, cache.ControlRevalidate); err != nil {
+			logger.Error("failed to encode worker", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
 	}
 }
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"go-api/internal/config"
+	"go-api/internal/metrics"
+	"go-api/internal/storage"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunAdLifecycle периодически снимает с публикации объявления с истёкшим сроком и заранее
+// напоминает владельцам о скором истечении. Блокирует до отмены ctx
+func RunAdLifecycle(ctx context.Context, db *gorm.DB, cfg config.Ads, logger *slog.Logger) {
+	if cfg.Disabled {
+		logger.Info("ad lifecycle job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := CheckAdExpiry(db.WithContext(ctx), cfg, time.Now(), logger); err != nil {
+			logger.Error("ad expiry check failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAdExpiry назначает срок объявлениям без срока, рассылает напоминания и переводит
+// объявления с истёкшим сроком в expired (с уведомлением владельцу)
+func CheckAdExpiry(db *gorm.DB, cfg config.Ads, now time.Time, logger *slog.Logger) error {
+	if n, err := storage.BackfillAdExpiry(db, now.Add(cfg.TTL)); err != nil {
+		return err
+	} else if n > 0 {
+		logger.Info("expiry date set for published ads", "count", n)
+	}
+
+	if cfg.ReminderBefore > 0 {
+		ads, err := storage.AdsToRemind(db, now, now.Add(cfg.ReminderBefore))
+		if err != nil {
+			return err
+		}
+		for _, ad := range ads {
+			message := fmt.Sprintf("Объявление «%s» будет снято с публикации %s. Закройте его, если мастер найден, или опубликуйте заново после истечения срока",
+				ad.Title, ad.ExpiresAt.Format("02.01.2006 15:04"))
+			err := db.Transaction(func(tx *gorm.DB) error {
+				marked, err := storage.MarkAdReminded(tx, ad.ID, now)
+				if err != nil || !marked {
+					return err
+				}
+				return storage.Notify(tx, ad.UserID, storage.NotifyAdExpiring, message, &ad.ID)
+			})
+			if err != nil {
+				logger.Error("failed to send ad expiry reminder", "error", err, "ad_id", ad.ID)
+			}
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		ads, err := storage.ExpireAds(tx, now)
+		if err != nil {
+			return err
+		}
+		for _, ad := range ads {
+			message := fmt.Sprintf("Срок публикации объявления «%s» истёк. Его можно опубликовать повторно", ad.Title)
+			if err := storage.Notify(tx, ad.UserID, storage.NotifyAdExpired, message, &ad.ID); err != nil {
+				return err
+			}
+		}
+		if len(ads) > 0 {
+			metrics.AdsExpired.Add(float64(len(ads)))
+			logger.Info("ads expired", "count", len(ads))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"go-api/internal/config"
+	"go-api/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunAnalytics периодически пересчитывает таблицы аналитики за последние cfg.Window.
+// При первом запуске (пустые таблицы) пересчитывает всю историю. Блокирует до отмены ctx
+func RunAnalytics(ctx context.Context, db *gorm.DB, cfg config.Analytics, logger *slog.Logger) {
+	if cfg.Disabled {
+		logger.Info("analytics job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		from := time.Now().Add(-cfg.Window)
+
+		var rows int64
+		db.Model(&models.StatsRollup{}).Count(&rows)
+		if rows == 0 {
+			from = time.Time{}
+		}
+
+		if err := RefreshAnalytics(db.WithContext(ctx), from); err != nil {
+			logger.Error("analytics refresh failed", "error", err)
+		} else {
+			logger.Debug("analytics refreshed", "from", from)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshAnalytics пересчитывает stats_rollups и ad_first_responses начиная с дня from
+func RefreshAnalytics(db *gorm.DB, from time.Time) error {
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day >= ?", day).Delete(&models.StatsRollup{}).Error; err != nil {
+			return err
+		}
+
+		// Все события сводятся в один поток и группируются по дню, категории и локации
+		err := tx.Exec(`
+			WITH events AS (
+				SELECT u.created_at::date AS day, NULL::bigint AS category_id, NULL::text AS location,
+					1 AS registrations, 0 AS ads_created, 0 AS ads_approved, 0 AS responses, 0 AS accepted
+				FROM users u
+				WHERE u.created_at >= @from AND u.deleted_at IS NULL
+				UNION ALL
+				SELECT a.created_at::date, a.category_id, lower(trim(a.location)), 0, 1, 0, 0, 0
+				FROM ads a
+				WHERE a.created_at >= @from AND a.deleted_at IS NULL
+				UNION ALL
+				-- одобрение считается в день первого одобрения (автомодерацией или администратором):
+				-- published_at сдвигается повторной публикацией, а решения при правках повторяются
+				SELECT ap.approved_at::date, a.category_id, lower(trim(a.location)), 0, 0, 1, 0, 0
+				FROM (
+					SELECT ad_id, MIN(approved_at) AS approved_at
+					FROM (
+						SELECT md.entity_id AS ad_id, md.created_at AS approved_at
+						FROM moderation_decisions md
+						WHERE md.entity_type = 'ad' AND md.decision = 'approve' AND md.deleted_at IS NULL
+						UNION ALL
+						SELECT al.target_id::bigint, al.created_at
+						FROM audit_logs al
+						WHERE al.action = 'ad.approve' AND al.target_type = 'ad'
+					) approvals
+					GROUP BY ad_id
+				) ap
+				JOIN ads a ON a.id = ap.ad_id
+				WHERE ap.approved_at >= @from AND a.deleted_at IS NULL
+				UNION ALL
+				SELECT r.created_at::date, a.category_id, lower(trim(a.location)),
+					0, 0, 0, 1, CASE WHEN r.status = 'accepted' THEN 1 ELSE 0 END
+				FROM responses r
+				JOIN ads a ON a.id = r.ad_id
+				WHERE r.created_at >= @from AND r.deleted_at IS NULL
+			)
+			INSERT INTO stats_rollups (day, dimension, dim_value, registrations, ads_created, ads_approved, responses, responses_accepted)
+			SELECT day,
+				CASE WHEN GROUPING(category_id) = 0 THEN 'category'
+					WHEN GROUPING(location) = 0 THEN 'location'
+					ELSE 'total' END,
+				COALESCE(CASE WHEN GROUPING(category_id) = 0 THEN category_id::text
+					WHEN GROUPING(location) = 0 THEN location END, ''),
+				SUM(registrations), SUM(ads_created), SUM(ads_approved), SUM(responses), SUM(accepted)
+			FROM events
+			GROUP BY GROUPING SETS ((day), (day, category_id), (day, location))
+			HAVING NOT (GROUPING(category_id) = 0 AND category_id IS NULL)
+				AND NOT (GROUPING(location) = 0 AND location IS NULL)`,
+			map[string]interface{}{"from": day}).Error
+		if err != nil {
+			return err
+		}
+
+		// Пересчитываются объявления, получившие отклики с from: объявление, созданное раньше окна,
+		// получает время первого отклика, как только отклик появится
+		recent := tx.Unscoped().Model(&models.Response{}).Select("ad_id").Where("created_at >= ?", day)
+		if err := tx.Where("day >= ? OR ad_id IN (?)", day, recent).Delete(&models.AdFirstResponse{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			INSERT INTO ad_first_responses (ad_id, day, category_id, location, seconds)
+			SELECT a.id, a.created_at::date, a.category_id, lower(trim(a.location)),
+				EXTRACT(EPOCH FROM MIN(r.created_at) - a.created_at)
+			FROM ads a
+			JOIN responses r ON r.ad_id = a.id AND r.deleted_at IS NULL
+			WHERE a.deleted_at IS NULL
+				AND a.id IN (SELECT r2.ad_id FROM responses r2 WHERE r2.created_at >= @from AND r2.deleted_at IS NULL)
+			GROUP BY a.id`,
+			map[string]interface{}{"from": day}).Error
+	})
+}
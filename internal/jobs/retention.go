@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"go-api/internal/config"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunRetention периодически окончательно удаляет записи, мягко удалённые раньше cfg.Period,
+// и истёкшие ключи идемпотентности. Блокирует до отмены ctx
+func RunRetention(ctx context.Context, db *gorm.DB, cfg config.Retention, logger *slog.Logger) {
+	if cfg.Disabled {
+		logger.Info("retention job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := Purge(db.WithContext(ctx), time.Now().Add(-cfg.Period), logger); err != nil {
+			logger.Error("retention purge failed", "error", err)
+		}
+		if n, err := storage.PurgeIdempotencyKeys(db.WithContext(ctx)); err != nil {
+			logger.Error("idempotency keys purge failed", "error", err)
+		} else if n > 0 {
+			logger.Info("expired idempotency keys purged", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge окончательно удаляет записи с deleted_at < cutoff.
+// Порядок учитывает внешние ключи: отклики → объявления → профили мастеров → пользователи → справочники (с переводами);
+// зависимые записи (предложения, брони, приглашения, уведомления, решения модерации) удаляются раньше родительских.
+// Записи, на которые ещё ссылаются живые строки, пропускаются
+func Purge(db *gorm.DB, cutoff time.Time, logger *slog.Logger) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		counts := map[string]int64{}
+
+		// Отклики: удалённые и отклики на удаляемые объявления, вместе с предложениями, бронями и решениями модерации
+		purgedAds := tx.Unscoped().Model(&models.Ad{}).Select("id").Where("deleted_at < ?", cutoff)
+		purgedResponses := tx.Unscoped().Model(&models.Response{}).Select("id").
+			Where("deleted_at < ? OR ad_id IN (?)", cutoff, purgedAds)
+		if err := tx.Where("response_id IN (?)", purgedResponses).Delete(&models.Offer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("response_id IN (?) OR ad_id IN (?)", purgedResponses, purgedAds).Delete(&models.Booking{}).Error; err != nil {
+			return err
+		}
+		if err := purgeModerationDecisions(tx, "response", purgedResponses); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN (?)", purgedResponses).Delete(&models.Response{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["responses"] = result.RowsAffected
+
+		// Объявления вместе с приглашениями, уведомлениями о них и решениями модерации
+		if err := tx.Where("ad_id IN (?)", purgedAds).Delete(&models.Invitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ad_id IN (?)", purgedAds).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := purgeModerationDecisions(tx, "ad", purgedAds); err != nil {
+			return err
+		}
+		result = tx.Unscoped().Where("id IN (?)", purgedAds).Delete(&models.Ad{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["ads"] = result.RowsAffected
+
+		// Профили мастеров вместе с категориями и отзывами о них
+		purgedWorkers := tx.Unscoped().Model(&models.WorkerProfile{}).Select("user_id").Where("deleted_at < ?", cutoff)
+		if err := tx.Where("worker_id IN (?)", purgedWorkers).Delete(&models.WorkerCategory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("worker_id IN (?)", purgedWorkers).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		result = tx.Unscoped().
+			Where("deleted_at < ? AND NOT EXISTS (SELECT 1 FROM responses r WHERE r.worker_id = worker_profiles.user_id)", cutoff).
+			Delete(&models.WorkerProfile{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["worker_profiles"] = result.RowsAffected
+
+		// Пользователи без оставшихся объявлений, профилей, отзывов и поданных жалоб, вместе с их уведомлениями
+		purgedUsers := tx.Unscoped().Model(&models.User{}).Select("id").
+			Where("deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM ads a WHERE a.user_id = users.id)").
+			Where("NOT EXISTS (SELECT 1 FROM worker_profiles wp WHERE wp.user_id = users.id)").
+			Where("NOT EXISTS (SELECT 1 FROM reviews rv WHERE rv.user_id = users.id)").
+			Where("NOT EXISTS (SELECT 1 FROM reports rp WHERE rp.reporter_id = users.id)")
+		if err := tx.Where("user_id IN (?)", purgedUsers).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		result = tx.Unscoped().Where("id IN (?)", purgedUsers).Delete(&models.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["users"] = result.RowsAffected
+
+		// Справочники, которые больше нигде не используются; переводы категорий удаляются вместе с ними
+		purgedCategories := tx.Unscoped().Model(&models.Category{}).Select("id").
+			Where("deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM ads a WHERE a.category_id = categories.id)").
+			Where("NOT EXISTS (SELECT 1 FROM worker_categories wc WHERE wc.category_id = categories.id)")
+		if err := tx.Where("category_id IN (?)", purgedCategories).Delete(&models.CategoryTranslation{}).Error; err != nil {
+			return err
+		}
+		result = tx.Unscoped().Where("id IN (?)", purgedCategories).Delete(&models.Category{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["categories"] = result.RowsAffected
+
+		result = tx.Unscoped().
+			Where("deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM ads a WHERE a.price_unit_id = price_units.id)").
+			Delete(&models.PriceUnit{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["price_units"] = result.RowsAffected
+
+		logger.Info("retention purge finished", "cutoff", cutoff, "purged", counts)
+		return nil
+	})
+}
+
+// purgeModerationDecisions удаляет решения модерации по удаляемым объектам типа entityType (ids - подзапрос)
+func purgeModerationDecisions(tx *gorm.DB, entityType string, ids *gorm.DB) error {
+	return tx.Unscoped().Where("entity_type = ? AND entity_id IN (?)", entityType, ids).Delete(&models.ModerationDecision{}).Error
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+)
+
+// Worker - запущенная фоновая задача, которую можно остановить и дождаться
+type Worker struct {
+	Name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start запускает run в отдельной горутине со своим контекстом
+func Start(name string, run func(ctx context.Context)) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Worker{Name: name, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+
+	return w
+}
+
+// Stop отменяет контекст задачи и ждёт её завершения (текущая итерация дорабатывает
+// или прерывается отменой запроса к БД), но не дольше ctx
+func (w *Worker) Stop(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("job %s did not stop: %w", w.Name, ctx.Err())
+	}
+}
@@ -0,0 +1,318 @@
+package matching
+
+import (
+	"fmt"
+	"go-api/internal/availability"
+	"go-api/internal/models"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Подбор пар объявление ↔ мастер: итоговая оценка 0-100 складывается из взвешенных компонентов,
+// у каждого - своя оценка 0..1 и пояснение
+
+// Компоненты оценки
+const (
+	ComponentCategory     = "category"     // категория объявления среди категорий мастера
+	ComponentLocation     = "location"     // совпадение локаций
+	ComponentAvailability = "availability" // свободное время мастера в желаемые сроки
+	ComponentRating       = "rating"       // отзывы о мастере
+	ComponentHistory      = "history"      // доля принятых откликов мастера
+	ComponentPrice        = "price"        // обычная цена мастера и бюджет объявления
+)
+
+// weights - вклад компонентов в итоговую оценку (в сумме 1)
+var weights = map[string]float64{
+	ComponentCategory:     0.30,
+	ComponentLocation:     0.20,
+	ComponentAvailability: 0.15,
+	ComponentRating:       0.15,
+	ComponentHistory:      0.10,
+	ComponentPrice:        0.10,
+}
+
+const (
+	neutral = 0.5 // оценка компонента, когда данных нет
+
+	ratingPrior       = 3.5 // рейтинг мастера без отзывов
+	ratingPriorWeight = 3   // сколько «виртуальных» отзывов с ratingPrior добавляется к настоящим
+
+	defaultWindowDays = 14 // окно поиска свободного времени, если сроки не заданы
+	urgentWindowDays  = 3  // окно для срочных заказов
+	maxWindow         = 31 * 24 * time.Hour
+	minFreeTime       = time.Hour // день подходит, если в нём столько свободного времени в желаемое время дня
+)
+
+// slotHours - часы времени дня [начало, конец) (см. models.TimeSlot*)
+var slotHours = map[string][2]int{
+	models.TimeSlotMorning:   {8, 12},
+	models.TimeSlotAfternoon: {12, 17},
+	models.TimeSlotEvening:   {17, 21},
+}
+
+// Component - вклад одного признака в оценку
+type Component struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	Score  float64 `json:"score"` // 0..1
+	Detail string  `json:"detail"`
+}
+
+// Match - итоговая оценка пары и её компоненты
+type Match struct {
+	Score      int         `json:"score"` // 0-100
+	Components []Component `json:"components"`
+}
+
+// Pair - признаки пары объявление ↔ мастер
+type Pair struct {
+	CategoryDistance int // 0 - мастер указал категорию объявления, 1 - родительскую и т.д.
+
+	AdLocation     string
+	WorkerLocation string
+
+	HasSchedule   bool // у мастера задано недельное расписание
+	AvailableDays int  // дней окна со свободным временем в желаемое время дня
+	WindowDays    int
+
+	RatingAvg   float64
+	RatingCount int
+
+	Accepted int // отклики мастера, принятые и отклонённые владельцами объявлений
+	Rejected int
+
+	TypicalPrice *float64 // медиана цен мастера в категории и единице цены объявления
+	BudgetMin    *float64
+	BudgetMax    *float64
+	Negotiable   bool
+}
+
+// Score считает оценку пары
+func Score(p Pair) Match {
+	components := []Component{
+		category(p),
+		location(p),
+		availabilityComponent(p),
+		rating(p),
+		history(p),
+		price(p),
+	}
+	var total float64
+	for i := range components {
+		components[i].Weight = weights[components[i].Name]
+		components[i].Score = math.Round(components[i].Score*100) / 100
+		total += components[i].Weight * components[i].Score
+	}
+	return Match{Score: int(math.Round(total * 100)), Components: components}
+}
+
+func category(p Pair) Component {
+	c := Component{Name: ComponentCategory, Score: 1 / float64(1+p.CategoryDistance)}
+	if p.CategoryDistance == 0 {
+		c.Detail = "worker lists the ad category"
+	} else {
+		c.Detail = fmt.Sprintf("worker lists a parent category (%d level(s) up)", p.CategoryDistance)
+	}
+	return c
+}
+
+func location(p Pair) Component {
+	c := Component{Name: ComponentLocation}
+	ad, worker := normalizeLocation(p.AdLocation), normalizeLocation(p.WorkerLocation)
+	switch {
+	case ad == "" || worker == "":
+		c.Score, c.Detail = neutral, "location not specified"
+	case ad == worker:
+		c.Score, c.Detail = 1, "same location"
+	case firstPart(ad) == firstPart(worker):
+		c.Score, c.Detail = 0.8, "same city"
+	case sharesWord(ad, worker):
+		c.Score, c.Detail = 0.5, "locations partially match"
+	default:
+		c.Score, c.Detail = 0, "different locations"
+	}
+	return c
+}
+
+func availabilityComponent(p Pair) Component {
+	c := Component{Name: ComponentAvailability}
+	switch {
+	case !p.HasSchedule:
+		c.Score, c.Detail = neutral, "worker has no weekly schedule"
+	case p.WindowDays == 0:
+		c.Score, c.Detail = 0, "desired dates are in the past"
+	default:
+		c.Score = float64(p.AvailableDays) / float64(p.WindowDays)
+		c.Detail = fmt.Sprintf("free on %d of %d days in the desired dates", p.AvailableDays, p.WindowDays)
+	}
+	return c
+}
+
+func rating(p Pair) Component {
+	c := Component{Name: ComponentRating}
+	// сглаживание: мастер с одним отзывом «5» не обгоняет мастера с сотней отзывов «4.9»
+	smoothed := (p.RatingAvg*float64(p.RatingCount) + ratingPrior*ratingPriorWeight) / float64(p.RatingCount+ratingPriorWeight)
+	c.Score = (smoothed - 1) / 4
+	if p.RatingCount == 0 {
+		c.Detail = "no reviews yet"
+	} else {
+		c.Detail = fmt.Sprintf("average rating %.1f from %d review(s)", p.RatingAvg, p.RatingCount)
+	}
+	return c
+}
+
+func history(p Pair) Component {
+	c := Component{Name: ComponentHistory}
+	decided := p.Accepted + p.Rejected
+	c.Score = float64(p.Accepted+1) / float64(decided+2)
+	if decided == 0 {
+		c.Detail = "no decided responses yet"
+	} else {
+		c.Detail = fmt.Sprintf("%d of %d responses accepted", p.Accepted, decided)
+	}
+	return c
+}
+
+func price(p Pair) Component {
+	c := Component{Name: ComponentPrice}
+	switch {
+	case p.BudgetMin == nil && p.BudgetMax == nil:
+		c.Score, c.Detail = neutral, "no budget, price negotiable"
+		return c
+	case p.TypicalPrice == nil:
+		c.Score, c.Detail = neutral, "no price history in this category"
+		return c
+	}
+
+	typical := *p.TypicalPrice
+	switch {
+	case p.BudgetMax != nil && typical > *p.BudgetMax:
+		c.Score = *p.BudgetMax / typical
+		c.Detail = fmt.Sprintf("typical price %.2f is above the budget", typical)
+	case p.BudgetMin != nil && typical < *p.BudgetMin:
+		c.Score = typical / *p.BudgetMin
+		c.Detail = fmt.Sprintf("typical price %.2f is below the budget", typical)
+	default:
+		c.Score = 1
+		c.Detail = fmt.Sprintf("typical price %.2f fits the budget", typical)
+	}
+	if p.Negotiable && c.Score < neutral {
+		c.Score = neutral
+		c.Detail += ", price negotiable"
+	}
+	return c
+}
+
+// normalizeLocation - нижний регистр, без лишних пробелов и префиксов вроде «г.»
+func normalizeLocation(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	for _, prefix := range []string{"г. ", "г.", "город "} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return s
+}
+
+// firstPart - часть локации до первой запятой (обычно город)
+func firstPart(s string) string {
+	city, _, _ := strings.Cut(s, ",")
+	return strings.TrimSpace(city)
+}
+
+// sharesWord - в локациях есть общее слово не короче трёх букв
+func sharesWord(a, b string) bool {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	words := map[string]bool{}
+	for _, w := range split(a) {
+		if utf8.RuneCountInString(w) >= 3 {
+			words[w] = true
+		}
+	}
+	for _, w := range split(b) {
+		if words[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// Window - окно поиска свободного времени для объявления: желаемые даты (по часовому поясу loc),
+// иначе ближайшие дни (для срочных заказов - меньше). Не раньше now и не длиннее месяца
+func Window(ad *models.Ad, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	now = now.In(loc)
+	from := now
+	if ad.DateFrom != nil {
+		dateFrom := time.Date(ad.DateFrom.Year(), ad.DateFrom.Month(), ad.DateFrom.Day(), 0, 0, 0, 0, loc)
+		if dateFrom.After(now) {
+			from = dateFrom
+		}
+	}
+
+	days := defaultWindowDays
+	if ad.Urgency == models.AdUrgencyUrgent {
+		days = urgentWindowDays
+	}
+	to := time.Date(from.Year(), from.Month(), from.Day()+days, 0, 0, 0, 0, loc)
+	if ad.DateTo != nil {
+		to = time.Date(ad.DateTo.Year(), ad.DateTo.Month(), ad.DateTo.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	}
+	if to.Sub(from) > maxWindow {
+		to = from.Add(maxWindow)
+	}
+	if to.Before(from) {
+		to = from
+	}
+	return from, to
+}
+
+// AvailableDays - сколько дней окна [from, to) (по часовому поясу loc) содержат не меньше часа
+// свободного времени в желаемое время дня (пустые slots - в любое время). Второе значение - число дней окна
+func AvailableDays(free []availability.Interval, slots models.TimeSlots, from, to time.Time, loc *time.Location) (int, int) {
+	if !to.After(from) {
+		return 0, 0
+	}
+	start := from.In(loc)
+	var available, total int
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		total++
+		var wanted []availability.Interval
+		if len(slots) == 0 {
+			wanted = append(wanted, availability.Interval{Start: day, End: day.AddDate(0, 0, 1)})
+		}
+		for _, slot := range slots {
+			hours := slotHours[slot]
+			wanted = append(wanted, availability.Interval{
+				Start: time.Date(day.Year(), day.Month(), day.Day(), hours[0], 0, 0, 0, loc),
+				End:   time.Date(day.Year(), day.Month(), day.Day(), hours[1], 0, 0, 0, loc),
+			})
+		}
+		if overlap(free, wanted) >= minFreeTime {
+			available++
+		}
+	}
+	return available, total
+}
+
+// overlap - суммарная длина пересечения интервалов a и b (интервалы внутри каждого не пересекаются)
+func overlap(a, b []availability.Interval) time.Duration {
+	var total time.Duration
+	for _, x := range a {
+		for _, y := range b {
+			start, end := x.Start, x.End
+			if y.Start.After(start) {
+				start = y.Start
+			}
+			if y.End.Before(end) {
+				end = y.End
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+	}
+	return total
+}
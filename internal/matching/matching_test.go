@@ -0,0 +1,242 @@
+package matching
+
+import (
+	"go-api/internal/availability"
+	"go-api/internal/models"
+	"testing"
+	"time"
+	_ "time/tzdata" // часовые пояса не зависят от системы, где запускаются тесты
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func componentScore(t *testing.T, m Match, name string) float64 {
+	t.Helper()
+	for _, c := range m.Components {
+		if c.Name == name {
+			return c.Score
+		}
+	}
+	t.Fatalf("component %s not found in %+v", name, m.Components)
+	return 0
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		pair Pair
+		want int
+	}{
+		{
+			// без данных компоненты нейтральны, категория совпадает
+			name: "нет данных",
+			pair: Pair{},
+			want: 67,
+		},
+		{
+			name: "лучшая пара",
+			pair: Pair{
+				AdLocation:     "г. Москва",
+				WorkerLocation: "москва",
+				HasSchedule:    true,
+				AvailableDays:  14,
+				WindowDays:     14,
+				RatingAvg:      5,
+				RatingCount:    1000,
+				Accepted:       98,
+				TypicalPrice:   ptr(100),
+				BudgetMin:      ptr(80),
+				BudgetMax:      ptr(120),
+			},
+			want: 100,
+		},
+		{
+			name: "худшая пара",
+			pair: Pair{
+				CategoryDistance: 1,
+				AdLocation:       "Казань",
+				WorkerLocation:   "Москва",
+				HasSchedule:      true,
+				WindowDays:       0,
+				RatingAvg:        1,
+				RatingCount:      1000,
+				Rejected:         98,
+				TypicalPrice:     ptr(200),
+				BudgetMax:        ptr(100),
+			},
+			want: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Score(tt.pair)
+			if m.Score != tt.want {
+				t.Errorf("Score = %d, want %d (%+v)", m.Score, tt.want, m.Components)
+			}
+			var sum float64
+			for _, c := range m.Components {
+				sum += c.Weight
+			}
+			if len(m.Components) != len(weights) || sum < 0.999 || sum > 1.001 {
+				t.Errorf("components %+v: want all %d with weights summing to 1", m.Components, len(weights))
+			}
+		})
+	}
+}
+
+func TestScoreComponents(t *testing.T) {
+	tests := []struct {
+		name      string
+		pair      Pair
+		component string
+		want      float64
+	}{
+		{"категория двумя уровнями выше", Pair{CategoryDistance: 2}, ComponentCategory, 0.33},
+		{"тот же город", Pair{AdLocation: "Москва, Тверская 1", WorkerLocation: "г. Москва, Арбат"}, ComponentLocation, 0.8},
+		{"общее слово в локации", Pair{AdLocation: "Химки, Московская обл.", WorkerLocation: "Балашиха, Московская обл."}, ComponentLocation, 0.5},
+		{"разные локации", Pair{AdLocation: "Казань", WorkerLocation: "Москва"}, ComponentLocation, 0},
+		{"локация мастера не указана", Pair{AdLocation: "Казань"}, ComponentLocation, neutral},
+		{"свободен не все дни", Pair{HasSchedule: true, AvailableDays: 3, WindowDays: 14}, ComponentAvailability, 0.21},
+		{"желаемые даты прошли", Pair{HasSchedule: true, WindowDays: 0}, ComponentAvailability, 0},
+		{"нет расписания", Pair{AvailableDays: 0, WindowDays: 14}, ComponentAvailability, neutral},
+		{"нет отзывов", Pair{}, ComponentRating, 0.63},
+		{"один отзыв 5 сглаживается к среднему", Pair{RatingAvg: 5, RatingCount: 1}, ComponentRating, 0.72},
+		{"история откликов", Pair{Accepted: 3, Rejected: 1}, ComponentHistory, 0.67},
+		{"цена выше бюджета", Pair{TypicalPrice: ptr(150), BudgetMax: ptr(100)}, ComponentPrice, 0.67},
+		{"цена ниже бюджета", Pair{TypicalPrice: ptr(50), BudgetMin: ptr(100)}, ComponentPrice, 0.5},
+		{"цена намного выше, но торг", Pair{TypicalPrice: ptr(400), BudgetMax: ptr(100), Negotiable: true}, ComponentPrice, neutral},
+		{"нет бюджета", Pair{TypicalPrice: ptr(100)}, ComponentPrice, neutral},
+		{"нет истории цен", Pair{BudgetMax: ptr(100)}, ComponentPrice, neutral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := componentScore(t, Score(tt.pair), tt.component); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.component, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	msk := mustLocation(t, "Europe/Moscow")
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, msk)
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 0, 0, 0, 0, msk) }
+	// даты объявления хранятся как date (полночь UTC)
+	adDate := func(month time.Month, d int) *time.Time {
+		t := time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		ad       models.Ad
+		from, to time.Time
+	}{
+		{"без сроков", models.Ad{Urgency: models.AdUrgencyNormal}, now, day(11, 2)},
+		{"срочный заказ", models.Ad{Urgency: models.AdUrgencyUrgent}, now, day(10, 22)},
+		{"начало в будущем", models.Ad{DateFrom: adDate(10, 25)}, day(10, 25), day(11, 8)},
+		{"начало в прошлом", models.Ad{DateFrom: adDate(10, 1), DateTo: adDate(10, 21)}, now, day(10, 22)},
+		{"сроки прошли", models.Ad{DateFrom: adDate(10, 1), DateTo: adDate(10, 10)}, now, now},
+		{"только конец", models.Ad{DateTo: adDate(10, 19)}, now, day(10, 20)},
+		{"не длиннее 31 дня", models.Ad{DateFrom: adDate(11, 1), DateTo: adDate(12, 31)}, day(11, 1), day(12, 2)},
+		{"без начала не длиннее 31 дня от now", models.Ad{DateTo: adDate(12, 31)}, now, now.Add(31 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := Window(&tt.ad, now, msk)
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("Window = [%s, %s), want [%s, %s)", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestAvailableDays(t *testing.T) {
+	msk := mustLocation(t, "Europe/Moscow")
+	berlin := mustLocation(t, "Europe/Berlin")
+	at := func(loc *time.Location, month time.Month, d, hour, min int) time.Time {
+		return time.Date(2026, month, d, hour, min, 0, 0, loc)
+	}
+	morning := models.TimeSlots{models.TimeSlotMorning}
+
+	tests := []struct {
+		name      string
+		free      []availability.Interval
+		slots     models.TimeSlots
+		from, to  time.Time
+		loc       *time.Location
+		available int
+		total     int
+	}{
+		{
+			name: "пустое окно",
+			free: []availability.Interval{{Start: at(msk, 10, 19, 9, 0), End: at(msk, 10, 19, 18, 0)}},
+			from: at(msk, 10, 19, 0, 0), to: at(msk, 10, 19, 0, 0), loc: msk,
+			available: 0, total: 0,
+		},
+		{
+			name: "без слотов подходит любое время",
+			free: []availability.Interval{
+				{Start: at(msk, 10, 19, 22, 0), End: at(msk, 10, 19, 23, 0)},
+				{Start: at(msk, 10, 21, 3, 0), End: at(msk, 10, 21, 4, 0)},
+			},
+			from: at(msk, 10, 19, 0, 0), to: at(msk, 10, 22, 0, 0), loc: msk,
+			available: 2, total: 3,
+		},
+		{
+			name:  "меньше часа в желаемое время",
+			free:  []availability.Interval{{Start: at(msk, 10, 19, 11, 1), End: at(msk, 10, 19, 14, 0)}},
+			slots: morning,
+			from:  at(msk, 10, 19, 0, 0), to: at(msk, 10, 20, 0, 0), loc: msk,
+			available: 0, total: 1,
+		},
+		{
+			name:  "свободно вечером, нужно утром",
+			free:  []availability.Interval{{Start: at(msk, 10, 19, 17, 0), End: at(msk, 10, 19, 21, 0)}},
+			slots: morning,
+			from:  at(msk, 10, 19, 0, 0), to: at(msk, 10, 20, 0, 0), loc: msk,
+			available: 0, total: 1,
+		},
+		{
+			name:  "час набирается из двух слотов",
+			free:  []availability.Interval{{Start: at(msk, 10, 19, 11, 30), End: at(msk, 10, 19, 12, 30)}},
+			slots: models.TimeSlots{models.TimeSlotMorning, models.TimeSlotAfternoon},
+			from:  at(msk, 10, 19, 0, 0), to: at(msk, 10, 20, 0, 0), loc: msk,
+			available: 1, total: 1,
+		},
+		{
+			name: "окно с середины дня считает этот день",
+			free: []availability.Interval{{Start: at(msk, 10, 20, 9, 0), End: at(msk, 10, 20, 18, 0)}},
+			from: at(msk, 10, 19, 15, 0), to: at(msk, 10, 21, 0, 0), loc: msk,
+			available: 1, total: 2,
+		},
+		{
+			name:  "переход на летнее время не меняет числа дней",
+			free:  []availability.Interval{{Start: at(berlin, 3, 29, 8, 0), End: at(berlin, 3, 29, 9, 0)}},
+			slots: morning,
+			from:  at(berlin, 3, 28, 0, 0), to: at(berlin, 3, 31, 0, 0), loc: berlin,
+			available: 1, total: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, total := AvailableDays(tt.free, tt.slots, tt.from, tt.to, tt.loc)
+			if available != tt.available || total != tt.total {
+				t.Errorf("AvailableDays = %d of %d, want %d of %d", available, total, tt.available, tt.total)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"go-api/internal/models"
+	"go-api/internal/tracing"
 	"log/slog"
 	"net/http"
 
@@ -13,6 +14,8 @@ import (
 func AdminMiddleware(db *gorm.DB, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			db, logger := tracing.Scope(r, db, logger)
+
 			// Получаем user_id из контекста (должен быть установлен в AuthMiddleware)
 			userID, ok := r.Context().Value("user_id").(uint)
 			if !ok {
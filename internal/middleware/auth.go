@@ -2,15 +2,23 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 
 	"go-api/internal/auth"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+func AuthMiddleware(db *gorm.DB, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			db, logger := tracing.Scope(r, db, logger)
+
 			// 1. Проверяем заголовок
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -35,7 +43,22 @@ func AuthMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 				return
 			}
 
-			// 4. Добавляем в контекст и передаем дальше
+			tracing.SetUserID(r, claims.UserID)
+
+			// 4. Проверяем полный бан аккаунта
+			suspension, err := storage.ActiveSuspension(db, claims.UserID, storage.SuspensionScopeLogin)
+			if err != nil {
+				logger.Error("failed to check suspension", "error", err, "user_id", claims.UserID)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+			if suspension != nil {
+				logger.Warn("suspended user rejected", "user_id", claims.UserID, "suspension_id", suspension.ID)
+				SuspendedError(w, suspension)
+				return
+			}
+
+			// 5. Добавляем в контекст и передаем дальше
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 			ctx = context.WithValue(ctx, "user_email", claims.Email)
 
@@ -43,3 +66,15 @@ func AuthMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// SuspendedError - ответ 403 для пользователя с действующим ограничением
+func SuspendedError(w http.ResponseWriter, s *models.Suspension) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  "account suspended",
+		"scope":  s.Scope,
+		"reason": s.Reason,
+		"until":  s.EndsAt,
+	})
+}
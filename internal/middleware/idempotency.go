@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"go-api/internal/storage"
+	"go-api/internal/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// Idempotency делает POST-запросы с заголовком Idempotency-Key безопасными для повтора.
+// Первый запрос выполняется и его ответ сохраняется на storage.IdempotencyTTL; повтор с тем же
+// ключом и тем же телом получает сохранённый ответ (с заголовком Idempotent-Replayed: true),
+// с другим телом - 409. Ключи разных пользователей (анонимных - разных IP) не пересекаются.
+// Ответы 5xx и 429 не сохраняются: такой запрос можно повторить с тем же ключом
+func Idempotency(db *gorm.DB, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				idempotencyError(w, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLen))
+				return
+			}
+
+			db, logger := tracing.Scope(r, db, logger)
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				idempotencyError(w, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := "ip:" + ClientIP(r)
+			if userID, ok := r.Context().Value("user_id").(uint); ok {
+				scope = fmt.Sprintf("user:%d", userID)
+			}
+
+			sum := sha256.New()
+			fmt.Fprintf(sum, "%s %s\n", r.Method, r.URL.Path)
+			sum.Write(body)
+			fingerprint := hex.EncodeToString(sum.Sum(nil))
+
+			record, reserved, err := storage.ReserveIdempotencyKey(db, scope, key, fingerprint)
+			if err != nil {
+				logger.Error("failed to reserve idempotency key", "error", err, "scope", scope)
+				idempotencyError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+
+			if !reserved {
+				switch {
+				case record.Fingerprint != fingerprint:
+					idempotencyError(w, http.StatusConflict, "Idempotency-Key was already used with a different request")
+				case record.StatusCode == 0:
+					w.Header().Set("Retry-After", "1")
+					idempotencyError(w, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+				default:
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(record.StatusCode)
+					w.Write(record.Body)
+				}
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			next.ServeHTTP(ww, r)
+
+			// ответ сохраняется, даже если клиент уже отключился - именно он и будет повторять запрос
+			db = db.WithContext(context.WithoutCancel(r.Context()))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// 429 и 5xx не результат запроса: его можно повторить с тем же ключом
+			if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+				err = storage.ReleaseIdempotencyKey(db, record)
+			} else {
+				err = storage.CompleteIdempotencyKey(db, record, status, ww.Header().Get("Content-Type"), buf.Bytes())
+			}
+			if err != nil {
+				logger.Error("failed to save idempotent response", "error", err, "scope", scope)
+			}
+		})
+	}
+}
+
+func idempotencyError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
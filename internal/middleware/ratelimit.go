@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-api/internal/metrics"
+	"go-api/internal/ratelimit"
+	"go-api/internal/tracing"
+)
+
+// RateLimit ограничивает частоту запросов группы group по IP клиента, а если запрос уже прошёл
+// AuthMiddleware - ещё и по пользователю: смена IP не обходит лимит пользователя, а много учётных
+// записей с одного IP - лимит IP. При ошибке хранилища запрос пропускается
+func RateLimit(group string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys := []string{"ip:" + ClientIP(r)}
+			if userID, ok := r.Context().Value("user_id").(uint); ok {
+				keys = append(keys, fmt.Sprintf("user:%d", userID))
+			}
+
+			for _, key := range keys {
+				ok, retryAfter, err := ratelimit.Allow(r.Context(), group, key)
+				if err != nil {
+					logger.Error("rate limit check failed", "group", group, "key", key, "error", err,
+						"trace_id", tracing.TraceID(r.Context()))
+				} else if !ok {
+					metrics.RateLimited.WithLabelValues(group).Inc()
+					TooManyRequests(w, retryAfter)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP - IP клиента из RemoteAddr (за доверенным прокси его заранее подменяет chi middleware.RealIP)
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// TooManyRequests - ответ 429 с Retry-After в секундах (с округлением вверх)
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       "too many requests",
+		"retry_after": seconds,
+	})
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Версии API
+const (
+	APIv1 = "v1"
+
+	versionLegacy = "legacy" // метка метрики для старых адресов
+)
+
+// APIVersion помечает запрос версией API. Обработчики, общие для нескольких версий,
+// читают её через Version и выбирают формат ответа, не дублируя бизнес-логику
+func APIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metrics.APIRequests.WithLabelValues(version).Inc()
+			ctx := context.WithValue(r.Context(), "api_version", version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Version - версия API запроса (APIv1, если маршрут не помечен)
+func Version(ctx context.Context) string {
+	if v, ok := ctx.Value("api_version").(string); ok {
+		return v
+	}
+	return APIv1
+}
+
+// сколько разных клиентов старых адресов запоминать, чтобы не писать в лог каждый запрос
+const maxLegacyClients = 1000
+
+// LegacyAlias обслуживает старые адреса без префикса (/ads вместо /api/v1/ads) обработчиками
+// версии successor и добавляет заголовки устаревания: Deprecation (RFC 9745), Sunset (RFC 8594)
+// и Link на новый адрес. Первое обращение каждого клиента к каждому маршруту пишется в лог
+func LegacyAlias(successor string, deprecatedAt, sunset time.Time, logger *slog.Logger) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetHeader := ""
+	if !sunset.IsZero() {
+		sunsetHeader = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = map[string]bool{}
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metrics.APIRequests.WithLabelValues(versionLegacy).Inc()
+
+			h := w.Header()
+			h.Set("Deprecation", deprecation)
+			if sunsetHeader != "" {
+				h.Set("Sunset", sunsetHeader)
+			}
+			h.Add("Link", fmt.Sprintf(`</api/%s%s>; rel="successor-version"`, successor, r.URL.Path))
+
+			ctx := context.WithValue(r.Context(), "api_version", successor)
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			// шаблон маршрута известен только после маршрутизации
+			route := ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			if route == "" || strings.HasSuffix(route, "*") {
+				return // несуществующий адрес
+			}
+
+			key := r.Method + " " + route + " " + r.UserAgent()
+			mu.Lock()
+			first := !seen[key] && len(seen) < maxLegacyClients
+			if first {
+				seen[key] = true
+			}
+			mu.Unlock()
+
+			if first {
+				logger.Info("deprecated route used",
+					"method", r.Method,
+					"route", route,
+					"successor", "/api/"+successor+route,
+					"user_agent", r.UserAgent(),
+					"ip", ClientIP(r),
+				)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,10 +22,22 @@ type Role struct {
 
 type Category struct {
 	gorm.Model
-	Name string `gorm:"size:255;not null;uniqueIndex" json:"name"`
+	Name      string `gorm:"size:255;not null;uniqueIndex" json:"name"`
+	Slug      string `gorm:"size:255;not null;default:''" json:"slug"` // стабильный идентификатор для клиентов (уникальный индекс создаётся в storage)
+	ParentID  *uint  `gorm:"index" json:"parent_id"`                   // NULL - корневая категория
+	SortOrder int    `gorm:"not null;default:0" json:"sort_order"`
+	Icon      string `gorm:"size:255" json:"icon"`
+
+	Translations     []CategoryTranslation `gorm:"foreignKey:CategoryID" json:"translations,omitempty"`
+	Ads              []Ad                  `gorm:"foreignKey:CategoryID" json:"ads,omitempty"`
+	WorkerCategories []WorkerCategory      `gorm:"foreignKey:CategoryID" json:"worker_categories,omitempty"`
+}
 
-	Ads              []Ad             `gorm:"foreignKey:CategoryID" json:"ads,omitempty"`
-	WorkerCategories []WorkerCategory `gorm:"foreignKey:CategoryID" json:"worker_categories,omitempty"`
+// CategoryTranslation - название категории на другом языке (Name в Category - название по умолчанию)
+type CategoryTranslation struct {
+	CategoryID uint   `gorm:"primaryKey" json:"-"`
+	Locale     string `gorm:"primaryKey;size:10" json:"locale"` // ru, en, ...
+	Name       string `gorm:"size:255;not null" json:"name"`
 }
 
 type PriceUnit struct {
@@ -32,6 +47,29 @@ type PriceUnit struct {
 	Ads []Ad `gorm:"foreignKey:PriceUnitID" json:"ads,omitempty"`
 }
 
+// ReferenceMerge - история слияний справочников (дубликаты переносятся в целевую запись)
+type ReferenceMerge struct {
+	gorm.Model
+	Entity                  string `gorm:"size:20;not null;index" json:"entity"` // category, price_unit
+	SourceIDs               string `gorm:"type:text;not null" json:"-"`          // JSON массив ID
+	SourceNames             string `gorm:"type:text;not null" json:"-"`          // JSON массив названий на момент слияния
+	TargetID                uint   `gorm:"not null;index" json:"target_id"`
+	AdsMoved                int64  `gorm:"not null;default:0" json:"ads_moved"`
+	WorkerLinksMoved        int64  `gorm:"not null;default:0" json:"worker_links_moved"`
+	WorkerLinksDeduplicated int64  `gorm:"not null;default:0" json:"worker_links_deduplicated"`
+	MergedBy                uint   `gorm:"not null" json:"merged_by"`
+}
+
+// ReferenceRedirect - старый ID справочника, влитого в другую запись.
+// Запросы со старым ID обслуживаются целевой записью
+type ReferenceRedirect struct {
+	Entity    string    `gorm:"primaryKey;size:20" json:"entity"`
+	FromID    uint      `gorm:"primaryKey;autoIncrement:false" json:"from_id"`
+	ToID      uint      `gorm:"not null;index" json:"to_id"`
+	MergeID   uint      `gorm:"not null;index" json:"merge_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ======================================================================
 // ОСНОВНЫЕ СУЩНОСТИ
 // ======================================================================
@@ -57,12 +95,13 @@ type WorkerProfile struct {
 	UserID      uint    `gorm:"not null;uniqueIndex" json:"-"`
 	ExpYears    *int    `json:"exp_years"` // NULLABLE
 	Description *string `gorm:"size:255" json:"description"`
-	IsBusy      bool    `gorm:"default:false;not null" json:"is_busy"`
-	Location    string  `gorm:"size:255" json:"location"` // место жительства / работы
-	Schedule    string  `gorm:"size:255" json:"schedule"` // расписание (часы / дни)
+	Location    string  `gorm:"size:255" json:"location"`                                 // место жительства / работы
+	Schedule    string  `gorm:"size:255" json:"schedule"`                                 // расписание (часы / дни), свободный текст для клиентов
+	Timezone    string  `gorm:"size:64;not null;default:'Europe/Moscow'" json:"timezone"` // часовой пояс недельного расписания (WorkerAvailability)
 	// Пометка, что профиль реально заполнен и пользователь считается "рабочим"
 	HaveWorkerProfile bool   `gorm:"default:false;not null" json:"have_worker_profile"`
 	Status            string `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, approved, rejected
+	Version           uint   `gorm:"not null;default:1" json:"version"`                      // растёт при каждом изменении (ETag, If-Match)
 
 	// Связи (UserID вместо ID!)
 	User            User             `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
@@ -70,17 +109,109 @@ type WorkerProfile struct {
 	Categories      []WorkerCategory `gorm:"foreignKey:WorkerID;references:UserID" json:"categories,omitempty"`
 }
 
+// Статусы объявления. Жизненный цикл: draft → pending → active ⇄ paused → closed;
+// active и paused истекают (expired) и публикуются повторно; rejected - решение модерации
+const (
+	AdStatusDraft    = "draft"    // черновик, не отправлен на модерацию
+	AdStatusPending  = "pending"  // на модерации
+	AdStatusActive   = "active"   // опубликовано, видно мастерам
+	AdStatusRejected = "rejected" // отклонено модерацией
+	AdStatusPaused   = "paused"   // скрыто владельцем на время
+	AdStatusClosed   = "closed"   // закрыто владельцем (ClosedReason)
+	AdStatusExpired  = "expired"  // истёк срок публикации
+)
+
+// Причины закрытия объявления
+const (
+	AdCloseFilled    = "filled"    // мастер найден
+	AdCloseCancelled = "cancelled" // заказ отменён
+)
+
+// AdModeratedStatuses - статусы объявлений, прошедших модерацию
+var AdModeratedStatuses = []string{AdStatusActive, AdStatusPaused, AdStatusClosed, AdStatusExpired}
+
+// Видимость объявления
+const (
+	AdVisibilityPublic  = "public"  // в общем списке, откликнуться может любой подходящий мастер
+	AdVisibilityPrivate = "private" // видно только приглашённым мастерам (Invitation)
+)
+
+// Срочность заказа
+const (
+	AdUrgencyFlexible = "flexible" // сроки не важны
+	AdUrgencyNormal   = "normal"
+	AdUrgencyUrgent   = "urgent" // нужно как можно скорее
+)
+
+// Желаемое время дня для работ
+const (
+	TimeSlotMorning   = "morning"   // 8:00-12:00
+	TimeSlotAfternoon = "afternoon" // 12:00-17:00
+	TimeSlotEvening   = "evening"   // 17:00-21:00
+)
+
+var TimeSlotNames = []string{TimeSlotMorning, TimeSlotAfternoon, TimeSlotEvening}
+
+// TimeSlots - набор времён дня, в БД хранится строкой через запятую. Пустой - в любое время
+type TimeSlots []string
+
+func (TimeSlots) GormDataType() string {
+	return "string"
+}
+
+func (t TimeSlots) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+func (t *TimeSlots) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unsupported time slots value %T", value)
+	}
+	*t = TimeSlots{}
+	if s != "" {
+		*t = strings.Split(s, ",")
+	}
+	return nil
+}
+
 type Ad struct {
 	gorm.Model
 	Title       string    `gorm:"size:255;not null" json:"title"`
-	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	Description string    `gorm:"type:text;not null;default:''" json:"description"`       // подробное описание работ
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`               // ориентир бюджета: верхняя граница (или нижняя), 0 - без бюджета
+	BudgetMin   *float64  `gorm:"type:decimal(10,2)" json:"budget_min"`                   // NULL - без нижней границы
+	BudgetMax   *float64  `gorm:"type:decimal(10,2)" json:"budget_max"`                   // NULL - без верхней границы
+	Negotiable  bool      `gorm:"not null;default:false" json:"negotiable"`               // цена договорная
+	Urgency     string    `gorm:"size:20;not null;default:'normal';index" json:"urgency"` // flexible, normal, urgent
 	CategoryID  uint      `gorm:"not null;index" json:"category_id"`
 	PriceUnitID uint      `gorm:"not null;index" json:"price_unit_id"`
 	UserID      uint      `gorm:"not null;index" json:"user_id"`
-	Location    string    `gorm:"size:255" json:"location"` // локация объявления
-	Schedule    string    `gorm:"size:255" json:"schedule"` // когда актуально объявление
+	Location    string    `gorm:"size:255" json:"location"`                                  // локация объявления
+	Visibility  string    `gorm:"size:20;not null;default:'public';index" json:"visibility"` // public, private
 	CreatedAt   time.Time `gorm:"not null;index" json:"created_at"`
-	Status      string    `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, approved, rejected
+
+	// Когда нужно выполнить работы: диапазон дат (NULL - без границы) и время дня
+	DateFrom  *time.Time `gorm:"type:date" json:"date_from"`
+	DateTo    *time.Time `gorm:"type:date" json:"date_to"`
+	TimeSlots TimeSlots  `gorm:"size:50;not null;default:''" json:"time_slots"`
+
+	Status  string `gorm:"size:20;not null;default:'pending';index" json:"status"` // draft, pending, active, rejected, paused, closed, expired
+	Version uint   `gorm:"not null;default:1" json:"version"`                      // растёт при каждом изменении (ETag, If-Match)
+
+	// Публикация: срок считается от одобрения или повторной публикации
+	PublishedAt    *time.Time `json:"published_at"`
+	ExpiresAt      *time.Time `gorm:"index" json:"expires_at"`
+	ReminderSentAt *time.Time `json:"-"` // напоминание об истечении уже отправлено
+
+	ClosedReason string     `gorm:"size:20" json:"closed_reason,omitempty"` // filled, cancelled
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
 
 	// Связи
 	Category  Category  `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
@@ -113,20 +244,241 @@ type Review struct {
 	Worker WorkerProfile `gorm:"foreignKey:WorkerID;references:UserID" json:"worker,omitempty"`
 }
 
+// Статусы отклика
+const (
+	ResponseStatusPending  = "pending"
+	ResponseStatusAccepted = "accepted" // принят владельцем объявления
+	ResponseStatusRejected = "rejected" // отклонён владельцем объявления
+)
+
+// Response - отклик мастера. На одно объявление мастер откликается один раз
+// (уникальный индекс по ad_id, worker_id среди неудалённых создаётся в storage)
 type Response struct {
 	gorm.Model
 	AdID          uint      `gorm:"not null;index" json:"ad_id"`
 	WorkerID      uint      `gorm:"not null;index" json:"worker_id"`                        // UserID мастера
 	Message       string    `gorm:"size:500" json:"message"`                                // сообщение от мастера
-	ProposedPrice *float64  `gorm:"type:decimal(10,2)" json:"proposed_price"`               // предлагаемая цена (опционально)
+	ProposedPrice *float64  `gorm:"type:decimal(10,2)" json:"proposed_price"`               // цена последнего предложения (опционально)
+	AgreedPrice   *float64  `gorm:"type:decimal(10,2)" json:"agreed_price"`                 // цена, зафиксированная при принятии отклика
 	Status        string    `gorm:"size:50;not null;default:'pending';index" json:"status"` // pending, accepted, rejected, cancelled
 	CreatedAt     time.Time `gorm:"not null;index" json:"created_at"`
 
+	ModerationStatus string `gorm:"size:20;not null;default:'approved';index" json:"moderation_status"` // pending, approved, rejected
+
+	InvitationID *uint `gorm:"index" json:"invitation_id,omitempty"` // отклик создан принятием приглашения
+
 	// Связи
 	Ad     Ad            `gorm:"foreignKey:AdID" json:"ad,omitempty"`
 	Worker WorkerProfile `gorm:"foreignKey:WorkerID;references:UserID" json:"worker,omitempty"`
 }
 
+// Статусы приглашения
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted" // мастер принял приглашение, создан отклик
+	InvitationDeclined = "declined" // мастер отказался
+	InvitationRevoked  = "revoked"  // владелец объявления отозвал приглашение
+)
+
+// Invitation - приглашение владельца объявления конкретному мастеру. Одно на мастера и объявление;
+// принятое приглашение создаёт отклик (Response.InvitationID) в обход публичного списка
+type Invitation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	AdID      uint      `gorm:"not null;uniqueIndex:idx_invitation_ad_worker" json:"ad_id"`
+	ClientID  uint      `gorm:"not null;index" json:"client_id"`
+	WorkerID  uint      `gorm:"not null;uniqueIndex:idx_invitation_ad_worker;index" json:"worker_id"`
+	Message   string    `gorm:"size:500" json:"message,omitempty"`
+	Status    string    `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, accepted, declined, revoked
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Стороны и статусы предложений по отклику
+const (
+	OfferPartyWorker = "worker"
+	OfferPartyClient = "client"
+
+	OfferOpen       = "open"
+	OfferSuperseded = "superseded" // сменилось новым предложением одной из сторон
+	OfferAccepted   = "accepted"   // принято другой стороной, цена зафиксирована в отклике
+	OfferClosed     = "closed"     // отклик отклонён или отозван
+)
+
+// Offer - предложение цены и условий по отклику. Стороны предлагают по очереди или пересматривают
+// своё предложение; открыто только последнее (частичный уникальный индекс создаётся в storage)
+type Offer struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ResponseID  uint      `gorm:"not null;index" json:"response_id"`
+	AuthorID    uint      `gorm:"not null" json:"author_id"`
+	Party       string    `gorm:"size:10;not null" json:"party"` // worker, client
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	PriceUnitID uint      `gorm:"not null" json:"price_unit_id"` // единица цены объявления на момент предложения
+	Terms       string    `gorm:"size:1000" json:"terms,omitempty"`
+	Status      string    `gorm:"size:20;not null;default:'open'" json:"status"` // open, superseded, accepted, closed
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+}
+
+// ModerationDecision - решение автомодерации и сработавшие правила
+type ModerationDecision struct {
+	gorm.Model
+	EntityType string `gorm:"size:20;not null;index:idx_moderation_entity" json:"entity_type"` // ad, response
+	EntityID   uint   `gorm:"not null;index:idx_moderation_entity" json:"entity_id"`
+	Decision   string `gorm:"size:20;not null;index" json:"decision"` // approve, reject, review
+	Rules      string `gorm:"type:text" json:"rules"`                 // JSON со сработавшими правилами
+}
+
+// Report - жалоба пользователя на объявление, мастера или отклик.
+// Один пользователь может пожаловаться на один объект только один раз
+type Report struct {
+	gorm.Model
+	ReporterID uint   `gorm:"not null;uniqueIndex:idx_report_unique" json:"reporter_id"`
+	TargetType string `gorm:"size:20;not null;uniqueIndex:idx_report_unique;index:idx_report_target" json:"target_type"` // ad, worker, response
+	TargetID   uint   `gorm:"not null;uniqueIndex:idx_report_unique;index:idx_report_target" json:"target_id"`
+	Reason     string `gorm:"size:50;not null" json:"reason"` // fraud, spam, abuse, inappropriate, other
+	Comment    string `gorm:"size:1000" json:"comment"`
+	Status     string `gorm:"size:20;not null;default:'open';index" json:"status"` // open, resolved, dismissed
+
+	// Решение администратора
+	Resolution        string     `gorm:"size:50" json:"resolution,omitempty"` // dismiss, delete_ad, reject_ad, reject_worker, delete_response, blacklist
+	ResolutionComment string     `gorm:"size:1000" json:"resolution_comment,omitempty"`
+	ResolvedBy        *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`
+
+	Reporter User `gorm:"foreignKey:ReporterID" json:"-"`
+}
+
+// Suspension - временное ограничение аккаунта.
+// Действует, пока starts_at <= now < ends_at (ends_at = NULL - бессрочно) и не снято вручную
+type Suspension struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	Scope    string     `gorm:"size:20;not null;index" json:"scope"` // ads, responses, login
+	Reason   string     `gorm:"size:500;not null" json:"reason"`
+	StartsAt time.Time  `gorm:"not null;index" json:"starts_at"`
+	EndsAt   *time.Time `gorm:"index" json:"ends_at"`
+
+	CreatedBy uint       `gorm:"not null" json:"created_by"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  *uint      `json:"lifted_by,omitempty"`
+}
+
+// AuditLog - журнал действий администраторов (только добавление записей).
+// Before/After содержат только изменившиеся поля
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+	ActorID    uint      `gorm:"not null;index" json:"actor_id"`
+	Action     string    `gorm:"size:50;not null;index" json:"action"` // например ad.approve, user.role_update
+	TargetType string    `gorm:"size:30;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"size:255;not null;index:idx_audit_target" json:"target_id"`
+	Before     string    `gorm:"type:text" json:"before"`
+	After      string    `gorm:"type:text" json:"after"`
+	RequestID  string    `gorm:"size:100;index" json:"request_id"`
+	IP         string    `gorm:"size:64" json:"ip"`
+}
+
+// ======================================================================
+// АНАЛИТИКА (предагрегированные данные, пересчитываются фоновой задачей)
+// ======================================================================
+
+// StatsRollup - дневные счётчики в разрезе: total (всё), category (dim_value = category_id), location
+type StatsRollup struct {
+	Day               time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Dimension         string    `gorm:"size:20;primaryKey" json:"dimension"`
+	DimValue          string    `gorm:"size:255;primaryKey" json:"dim_value"`
+	Registrations     int64     `gorm:"not null;default:0" json:"registrations"`
+	AdsCreated        int64     `gorm:"not null;default:0" json:"ads_created"`
+	AdsApproved       int64     `gorm:"not null;default:0" json:"ads_approved"`
+	Responses         int64     `gorm:"not null;default:0" json:"responses"`
+	ResponsesAccepted int64     `gorm:"not null;default:0" json:"responses_accepted"`
+}
+
+// AdFirstResponse - время до первого отклика по каждому объявлению (для медианы по любому периоду)
+type AdFirstResponse struct {
+	AdID       uint      `gorm:"primaryKey;autoIncrement:false" json:"ad_id"`
+	Day        time.Time `gorm:"type:date;not null;index" json:"day"` // день создания объявления
+	CategoryID uint      `gorm:"not null;index" json:"category_id"`
+	Location   string    `gorm:"size:255" json:"location"`
+	Seconds    float64   `gorm:"not null" json:"seconds"`
+}
+
+// IdempotencyKey - ответ на POST-запрос с заголовком Idempotency-Key.
+// Повтор с тем же ключом получает сохранённый ответ, пока запись не истекла
+type IdempotencyKey struct {
+	Scope       string    `gorm:"primaryKey;size:100" json:"scope"` // user:123 или ip:1.2.3.4 - ключи разных клиентов не пересекаются
+	Key         string    `gorm:"primaryKey;size:255" json:"key"`
+	Fingerprint string    `gorm:"size:64;not null" json:"fingerprint"`   // sha256 метода, пути и тела запроса
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"` // 0 - запрос ещё выполняется
+	ContentType string    `gorm:"size:255" json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// ======================================================================
+// Расписание мастеров
+// ======================================================================
+
+// WorkerAvailability - интервал недельного расписания мастера: в этот день недели мастер работает
+// с Start до End (местное время, WorkerProfile.Timezone). В один день может быть несколько интервалов
+type WorkerAvailability struct {
+	ID       uint   `gorm:"primaryKey" json:"-"`
+	WorkerID uint   `gorm:"not null;index" json:"-"`      // UserID мастера
+	Weekday  int    `gorm:"not null" json:"weekday"`      // 1 - понедельник ... 7 - воскресенье
+	Start    string `gorm:"size:5;not null" json:"start"` // HH:MM
+	End      string `gorm:"size:5;not null" json:"end"`   // HH:MM, позже Start
+}
+
+// Причины исключений из расписания
+const (
+	ExceptionVacation = "vacation" // отпуск
+	ExceptionBooked   = "booked"   // день занят заказом вне платформы
+	ExceptionOther    = "other"
+)
+
+// AvailabilityException - дни (включительно, по часовому поясу мастера), когда мастер не работает
+// независимо от недельного расписания
+type AvailabilityException struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	WorkerID  uint      `gorm:"not null;index" json:"-"`
+	DateFrom  time.Time `gorm:"type:date;not null" json:"date_from"`
+	DateTo    time.Time `gorm:"type:date;not null" json:"date_to"`
+	Reason    string    `gorm:"size:20;not null" json:"reason"` // vacation, booked, other
+	Note      string    `gorm:"size:255" json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Статусы брони
+const (
+	BookingActive    = "active"
+	BookingCancelled = "cancelled" // отклик отозван или отменён
+)
+
+// Booking - забронированное время мастера по принятому отклику. Активные брони одного мастера не пересекаются
+type Booking struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	WorkerID   uint      `gorm:"not null;index:idx_booking_worker_time" json:"worker_id"`
+	ClientID   uint      `gorm:"not null;index" json:"client_id"`
+	AdID       uint      `gorm:"not null;index" json:"ad_id"`
+	ResponseID uint      `gorm:"not null;index" json:"response_id"`
+	StartsAt   time.Time `gorm:"not null;index:idx_booking_worker_time" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null" json:"ends_at"`
+	Status     string    `gorm:"size:20;not null;default:'active';index" json:"status"` // active, cancelled
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Notification - уведомление пользователю в личном кабинете (GET /notifications)
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"-"`
+	Kind      string     `gorm:"size:50;not null" json:"kind"` // вид уведомления, см. storage.Notify*
+	Message   string     `gorm:"size:1000;not null" json:"message"`
+	AdID      *uint      `gorm:"index" json:"ad_id,omitempty"` // объявление, к которому относится уведомление
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"not null;index" json:"created_at"`
+}
+
 type BlackList struct {
 	Email string `gorm:"primaryKey;size:255;not null" json:"email"`
 }
@@ -0,0 +1,145 @@
+package moderation
+
+import (
+	"encoding/json"
+	"go-api/internal/config"
+	"go-api/internal/metrics"
+	"go-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Действия правил
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+	ActionReview  = "review"
+)
+
+// Типы проверяемых сущностей
+const (
+	EntityAd       = "ad"
+	EntityResponse = "response"
+)
+
+var cfg config.Moderation
+
+func Init(c config.Moderation) {
+	cfg = c
+}
+
+// Subject - то, что проверяется правилами (объявление или отклик)
+type Subject struct {
+	EntityType  string
+	UserID      uint
+	Title       string
+	Text        string
+	Price       *float64
+	CategoryID  uint
+	PriceUnitID uint
+}
+
+// FiredRule - сработавшее правило
+type FiredRule struct {
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type Result struct {
+	Decision string      `json:"decision"` // approve, reject, review
+	Fired    []FiredRule `json:"fired"`
+}
+
+// Status - статус модерации для записи в сущность (pending, approved, rejected)
+func (r Result) Status() string {
+	switch r.Decision {
+	case ActionApprove:
+		return "approved"
+	case ActionReject:
+		return "rejected"
+	default:
+		return "pending"
+	}
+}
+
+// AdStatus - статус объявления по решению: одобренное сразу публикуется (active)
+func (r Result) AdStatus() string {
+	if r.Decision == ActionApprove {
+		return models.AdStatusActive
+	}
+	return r.Status()
+}
+
+// Evaluate прогоняет все правила. Приоритет: reject > review > approve.
+// Если ничего не сработало, решение зависит от auto_approve.
+func Evaluate(db *gorm.DB, s Subject) Result {
+	if cfg.Disabled {
+		return Result{Decision: ActionReview}
+	}
+
+	var fired []FiredRule
+	for _, rule := range rules {
+		if f, ok := rule(db, s); ok {
+			fired = append(fired, f)
+		}
+	}
+
+	decision := ActionReview
+	if cfg.AutoApprove {
+		decision = ActionApprove
+	}
+	if len(fired) > 0 {
+		decision = strongest(fired)
+	}
+
+	return Result{Decision: decision, Fired: fired}
+}
+
+// Record сохраняет решение вместе со сработавшими правилами
+func Record(db *gorm.DB, entityType string, entityID uint, res Result) error {
+	if err := save(db, entityType, entityID, res); err != nil {
+		return err
+	}
+	metrics.ModerationDecisions.WithLabelValues(entityType, res.Decision, "auto").Inc()
+	return nil
+}
+
+// RecordManual сохраняет решение администратора (approve, reject) в ту же историю решений;
+// метрику с source=manual считает обработчик
+func RecordManual(db *gorm.DB, entityType string, entityID uint, decision string) error {
+	return save(db, entityType, entityID, Result{
+		Decision: decision,
+		Fired:    []FiredRule{{Rule: "admin", Action: decision, Reason: "manual decision"}},
+	})
+}
+
+func save(db *gorm.DB, entityType string, entityID uint, res Result) error {
+	rulesJSON, err := json.Marshal(res.Fired)
+	if err != nil {
+		return err
+	}
+
+	decision := models.ModerationDecision{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Decision:   res.Decision,
+		Rules:      string(rulesJSON),
+	}
+	decision.CreatedAt = time.Now()
+
+	return db.Create(&decision).Error
+}
+
+func strongest(fired []FiredRule) string {
+	weight := map[string]int{ActionApprove: 1, ActionReview: 2, ActionReject: 3}
+
+	decision := ActionApprove
+	for _, f := range fired {
+		if weight[f.Action] > weight[decision] {
+			decision = f.Action
+		}
+	}
+	return decision
+}
@@ -0,0 +1,160 @@
+package moderation
+
+import (
+	"fmt"
+	"go-api/internal/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type rule func(db *gorm.DB, s Subject) (FiredRule, bool)
+
+var rules = []rule{
+	bannedWords,
+	contactsInTitle,
+	priceOutlier,
+	newAccountVelocity,
+	trustedAccount,
+}
+
+var (
+	phonePattern = regexp.MustCompile(`(\+7|8)?[\s\-(]*\d{3}[\s\-)]*\d{3}[\s\-]*\d{2}[\s\-]*\d{2}`)
+	urlPattern   = regexp.MustCompile(`(?i)(https?://|www\.|t\.me/|@[a-z0-9_]{4,})|[a-z0-9\-]+\.(ru|com|net|org|su|me|io)\b`)
+)
+
+// неизвестное действие из конфига отправляет на ручную проверку
+func action(a string) string {
+	switch a {
+	case ActionApprove, ActionReject, ActionReview:
+		return a
+	default:
+		return ActionReview
+	}
+}
+
+// bannedWords - запрещённые слова в заголовке или тексте
+func bannedWords(db *gorm.DB, s Subject) (FiredRule, bool) {
+	text := strings.ToLower(s.Title + " " + s.Text)
+	for _, word := range cfg.BannedWords.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(text, word) {
+			return FiredRule{
+				Rule:   "banned_words",
+				Action: action(cfg.BannedWords.Action),
+				Reason: fmt.Sprintf("contains banned word %q", word),
+			}, true
+		}
+	}
+	return FiredRule{}, false
+}
+
+// contactsInTitle - телефон или ссылка в заголовке объявления
+func contactsInTitle(db *gorm.DB, s Subject) (FiredRule, bool) {
+	if s.Title == "" {
+		return FiredRule{}, false
+	}
+
+	reason := ""
+	switch {
+	case phonePattern.MatchString(s.Title):
+		reason = "phone number in title"
+	case urlPattern.MatchString(s.Title):
+		reason = "url in title"
+	default:
+		return FiredRule{}, false
+	}
+
+	return FiredRule{
+		Rule:   "contacts_in_title",
+		Action: action(cfg.ContactsInTitle.Action),
+		Reason: reason,
+	}, true
+}
+
+// priceOutlier - цена сильно отличается от медианы одобренных объявлений
+// той же категории и единицы цены (договорные объявления без бюджета не учитываются)
+func priceOutlier(db *gorm.DB, s Subject) (FiredRule, bool) {
+	if s.Price == nil || *s.Price <= 0 || s.CategoryID == 0 || s.PriceUnitID == 0 || cfg.PriceOutlier.Factor <= 1 {
+		return FiredRule{}, false
+	}
+
+	var stats struct {
+		Samples int64
+		Median  float64
+	}
+	err := db.Model(&models.Ad{}).
+		Select("COUNT(*) as samples, COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY price), 0) as median").
+		Where("category_id = ? AND price_unit_id = ? AND status IN ? AND price > 0", s.CategoryID, s.PriceUnitID, models.AdModeratedStatuses).
+		Scan(&stats).Error
+	if err != nil || stats.Samples < cfg.PriceOutlier.MinSamples || stats.Median <= 0 {
+		return FiredRule{}, false
+	}
+
+	price := *s.Price
+	if price > stats.Median*cfg.PriceOutlier.Factor || price < stats.Median/cfg.PriceOutlier.Factor {
+		return FiredRule{
+			Rule:   "price_outlier",
+			Action: action(cfg.PriceOutlier.Action),
+			Reason: fmt.Sprintf("price %.2f is far from category median %.2f", price, stats.Median),
+		}, true
+	}
+	return FiredRule{}, false
+}
+
+// newAccountVelocity - новый аккаунт публикует слишком много за короткое время
+func newAccountVelocity(db *gorm.DB, s Subject) (FiredRule, bool) {
+	var user models.User
+	if err := db.Select("id, created_at").First(&user, s.UserID).Error; err != nil {
+		return FiredRule{}, false
+	}
+	if time.Since(user.CreatedAt) > cfg.NewAccountVelocity.AccountAge {
+		return FiredRule{}, false
+	}
+
+	since := time.Now().Add(-cfg.NewAccountVelocity.Window)
+	var count int64
+	if s.EntityType == EntityResponse {
+		db.Model(&models.Response{}).Where("worker_id = ? AND created_at >= ?", s.UserID, since).Count(&count)
+	} else {
+		db.Model(&models.Ad{}).Where("user_id = ? AND created_at >= ?", s.UserID, since).Count(&count)
+	}
+
+	if count >= cfg.NewAccountVelocity.MaxItems {
+		return FiredRule{
+			Rule:   "new_account_velocity",
+			Action: action(cfg.NewAccountVelocity.Action),
+			Reason: fmt.Sprintf("new account published %d items within %s", count, cfg.NewAccountVelocity.Window),
+		}, true
+	}
+	return FiredRule{}, false
+}
+
+// trustedAccount - давний пользователь с одобренными объявлениями
+func trustedAccount(db *gorm.DB, s Subject) (FiredRule, bool) {
+	if cfg.TrustedAccount.MinApprovedAds <= 0 {
+		return FiredRule{}, false
+	}
+
+	var user models.User
+	if err := db.Select("id, created_at").First(&user, s.UserID).Error; err != nil {
+		return FiredRule{}, false
+	}
+	if time.Since(user.CreatedAt) < cfg.TrustedAccount.MinAccountAge {
+		return FiredRule{}, false
+	}
+
+	var approved int64
+	db.Model(&models.Ad{}).Where("user_id = ? AND status IN ?", s.UserID, models.AdModeratedStatuses).Count(&approved)
+	if approved < cfg.TrustedAccount.MinApprovedAds {
+		return FiredRule{}, false
+	}
+
+	return FiredRule{
+		Rule:   "trusted_account",
+		Action: action(cfg.TrustedAccount.Action),
+		Reason: fmt.Sprintf("account has %d approved ads", approved),
+	}, true
+}
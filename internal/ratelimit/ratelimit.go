@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"go-api/internal/config"
+	"strings"
+	"time"
+)
+
+// Группы лимитов (см. config.RateLimit)
+const (
+	GroupGlobal = "global"
+	GroupAuth   = "auth"
+	GroupCreate = "create"
+)
+
+var (
+	cfg   config.RateLimit
+	store Store = NewMemoryStore()
+)
+
+func Init(c config.RateLimit) {
+	cfg = c
+}
+
+// SetStore подменяет хранилище (например, общим для нескольких экземпляров API)
+func SetStore(s Store) {
+	store = s
+}
+
+// Allow проверяет лимит группы group для клиента key (IP или пользователь).
+// Если лимит исчерпан - возвращает false и через сколько повторить
+func Allow(ctx context.Context, group, key string) (bool, time.Duration, error) {
+	if cfg.Disabled {
+		return true, 0, nil
+	}
+	limit, ok := cfg.Limits()[group]
+	if !ok || limit.Requests <= 0 || limit.Period <= 0 {
+		return true, 0, nil
+	}
+	return store.Take(ctx, group+":"+key, *limit)
+}
+
+// LoginLocked - сколько ещё закрыт вход для email после серии неудачных попыток
+func LoginLocked(ctx context.Context, email string) (time.Duration, error) {
+	if cfg.Disabled {
+		return 0, nil
+	}
+	return store.Locked(ctx, loginKey(email))
+}
+
+// LoginFailed засчитывает неудачный вход и возвращает наступившую блокировку
+func LoginFailed(ctx context.Context, email string) (time.Duration, error) {
+	if cfg.Disabled || cfg.Login.MaxFailures <= 0 {
+		return 0, nil
+	}
+	return store.Fail(ctx, loginKey(email), cfg.Login)
+}
+
+// LoginSucceeded сбрасывает счётчик неудач после успешного входа
+func LoginSucceeded(ctx context.Context, email string) error {
+	if cfg.Disabled {
+		return nil
+	}
+	return store.Reset(ctx, loginKey(email))
+}
+
+func loginKey(email string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(email))
+}
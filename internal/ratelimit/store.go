@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"go-api/internal/config"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store хранит состояние лимитов. MemoryStore подходит для одного экземпляра API;
+// при нескольких экземплярах нужна общая реализация (например, на Redis) с той же семантикой
+type Store interface {
+	// Take забирает токен из корзины key. Если токенов нет - ok=false и время до появления следующего
+	Take(ctx context.Context, key string, limit config.Limit) (ok bool, retryAfter time.Duration, err error)
+
+	// Locked - сколько ещё действует блокировка key (0 - не заблокирован)
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// Fail засчитывает неудачную попытку и возвращает срок блокировки, если она наступила
+	Fail(ctx context.Context, key string, policy config.LoginLockout) (time.Duration, error)
+	// Reset сбрасывает счётчик неудач (успешный вход)
+	Reset(ctx context.Context, key string) error
+}
+
+// как часто выбрасывать из памяти полные корзины и забытые неудачи
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // к этому моменту корзина заполнится и её можно удалить
+}
+
+type failures struct {
+	count       int
+	lockedUntil time.Time
+	expires     time.Time // после этого запись не нужна
+}
+
+// MemoryStore - Store в памяти процесса
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	failures  map[string]*failures
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  map[string]*bucket{},
+		failures: map[string]*failures{},
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit config.Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	rate := float64(limit.Requests) / limit.Period.Seconds() // токенов в секунду
+	burst := float64(limit.Burst)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		retry := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, retry, nil
+	}
+
+	b.tokens--
+	b.full = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+	return true, 0, nil
+}
+
+func (s *MemoryStore) Locked(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(f.lockedUntil.Sub(s.now()), 0), nil
+}
+
+func (s *MemoryStore) Fail(_ context.Context, key string, policy config.LoginLockout) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	f, ok := s.failures[key]
+	if !ok || now.After(f.expires) {
+		f = &failures{}
+		s.failures[key] = f
+	}
+
+	f.count++
+	f.expires = now.Add(policy.ResetAfter)
+
+	if f.count < policy.MaxFailures {
+		return 0, nil
+	}
+
+	lock := lockoutFor(f.count-policy.MaxFailures, policy)
+	f.lockedUntil = now.Add(lock)
+	if f.lockedUntil.After(f.expires) {
+		f.expires = f.lockedUntil
+	}
+	return lock, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+// sweep удаляет записи, которые уже ни на что не влияют. Вызывается под s.mu
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if now.After(f.expires) {
+			delete(s.failures, key)
+		}
+	}
+}
+
+// lockoutFor - срок блокировки после extra неудач сверх порога: Lockout, 2*Lockout, 4*Lockout... до MaxLockout
+func lockoutFor(extra int, policy config.LoginLockout) time.Duration {
+	lock := policy.Lockout
+	for i := 0; i < extra && lock < policy.MaxLockout; i++ {
+		lock *= 2
+	}
+	return min(lock, policy.MaxLockout)
+}
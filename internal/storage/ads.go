@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"go-api/internal/config"
+	"go-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Жизненный цикл объявлений (статусы - models.AdStatus*)
+
+// срок публикации, задаётся InitAds
+var adTTL = 30 * 24 * time.Hour
+
+func InitAds(cfg config.Ads) {
+	adTTL = cfg.TTL
+}
+
+// AdExpiry - когда истечёт объявление, опубликованное в publishedAt
+func AdExpiry(publishedAt time.Time) time.Time {
+	return publishedAt.Add(adTTL)
+}
+
+// AdPublication - поля публикации объявления (одобрение, повторная публикация): срок отсчитывается заново
+func AdPublication(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"published_at":     now,
+		"expires_at":       AdExpiry(now),
+		"reminder_sent_at": nil,
+	}
+}
+
+// MigrateAdStatuses переводит одобренные объявления (статус approved до появления жизненного цикла) в active
+func MigrateAdStatuses(db *gorm.DB) error {
+	return db.Model(&models.Ad{}).Where("status = ?", "approved").
+		Updates(map[string]interface{}{
+			"status":       models.AdStatusActive,
+			"published_at": gorm.Expr("COALESCE(published_at, updated_at)"),
+		}).Error
+}
+
+// MigrateAdContent переносит прежние поля объявлений в структурированные: единственная цена становится
+// фиксированным бюджетом (budget_min = budget_max = price), а строка schedule - абзацем описания
+func MigrateAdContent(db *gorm.DB) error {
+	err := db.Model(&models.Ad{}).Unscoped().
+		Where("budget_min IS NULL AND budget_max IS NULL AND NOT negotiable AND price > 0").
+		Updates(map[string]interface{}{"budget_min": gorm.Expr("price"), "budget_max": gorm.Expr("price")}).Error
+	if err != nil {
+		return err
+	}
+
+	if !db.Migrator().HasColumn(&models.Ad{}, "schedule") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE ads SET description = CASE WHEN description = '' THEN 'Сроки: ' || schedule
+			ELSE description || E'\n\nСроки: ' || schedule END
+			WHERE COALESCE(schedule, '') <> ''`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&models.Ad{}, "schedule")
+	})
+}
+
+// BackfillAdExpiry назначает срок expiresAt опубликованным объявлениям без срока
+// (опубликованным до появления сроков), чтобы они не истекли без напоминания
+func BackfillAdExpiry(db *gorm.DB, expiresAt time.Time) (int64, error) {
+	result := db.Model(&models.Ad{}).
+		Where("status IN ? AND expires_at IS NULL", []string{models.AdStatusActive, models.AdStatusPaused}).
+		Update("expires_at", expiresAt)
+	return result.RowsAffected, result.Error
+}
+
+// AdsToRemind - опубликованные объявления, истекающие до deadline, о которых ещё не напомнили
+func AdsToRemind(db *gorm.DB, now, deadline time.Time) ([]models.Ad, error) {
+	var ads []models.Ad
+	err := db.Select("id, user_id, title, expires_at").
+		Where("status IN ? AND reminder_sent_at IS NULL", []string{models.AdStatusActive, models.AdStatusPaused}).
+		Where("expires_at > ? AND expires_at <= ?", now, deadline).
+		Find(&ads).Error
+	return ads, err
+}
+
+// MarkAdReminded отмечает отправку напоминания. false - напоминание уже отправлено
+// (другим экземпляром задачи) или объявление успели снять с публикации
+func MarkAdReminded(db *gorm.DB, adID uint, now time.Time) (bool, error) {
+	result := db.Model(&models.Ad{}).
+		Where("id = ? AND reminder_sent_at IS NULL AND status IN ?", adID, []string{models.AdStatusActive, models.AdStatusPaused}).
+		UpdateColumn("reminder_sent_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ExpireAds переводит опубликованные объявления с истёкшим сроком в expired и возвращает их
+func ExpireAds(db *gorm.DB, now time.Time) ([]models.Ad, error) {
+	var ads []models.Ad
+	err := db.Model(&ads).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "user_id"}, {Name: "title"}}}).
+		Where("status IN ? AND expires_at <= ?", []string{models.AdStatusActive, models.AdStatusPaused}, now).
+		Updates(map[string]interface{}{"status": models.AdStatusExpired, "version": NextVersion()}).Error
+	return ads, err
+}
+
+// DeleteAd мягко удаляет объявление: торг по его откликам закрывается, брони мастеров отменяются
+func DeleteAd(db *gorm.DB, ad *models.Ad) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(ad).Error; err != nil {
+			return err
+		}
+		return ReleaseAd(tx, ad.ID)
+	})
+}
+
+// ReleaseAd закрывает торг по откликам объявления и отменяет брони мастеров
+// (объявление удалено или отклонено модератором)
+func ReleaseAd(db *gorm.DB, adID uint) error {
+	if err := CloseAdOffers(db, adID); err != nil {
+		return err
+	}
+	return CancelAdBookings(db, adID)
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"go-api/internal/availability"
+	"go-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Расписание мастеров и брони времени
+
+// workerBusySQL - мастер занят сейчас: сегодня (по его часовому поясу) действует исключение
+// из расписания или идёт активная бронь. Аргументы: now, now, now
+const workerBusySQL = `(EXISTS (SELECT 1 FROM availability_exceptions e WHERE e.worker_id = wp.user_id
+		AND (?::timestamptz AT TIME ZONE wp.timezone)::date BETWEEN e.date_from AND e.date_to)
+	OR EXISTS (SELECT 1 FROM bookings b WHERE b.worker_id = wp.user_id AND b.status = 'active'
+		AND b.starts_at <= ? AND b.ends_at > ?)) as is_busy`
+
+// ErrSlotUnavailable - время вне расписания мастера (или в день-исключение)
+var ErrSlotUnavailable = errors.New("slot is outside worker availability")
+
+// SlotConflict - время пересекается с другой активной бронью мастера
+type SlotConflict struct {
+	Booking models.Booking
+}
+
+func (e *SlotConflict) Error() string {
+	return fmt.Sprintf("slot overlaps booking %d", e.Booking.ID)
+}
+
+// WorkerLocation - часовой пояс расписания мастера
+func WorkerLocation(db *gorm.DB, workerID uint) (*time.Location, error) {
+	var profile models.WorkerProfile
+	if err := db.Select("timezone").Where("user_id = ?", workerID).Take(&profile).Error; err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(profile.Timezone)
+}
+
+// WeeklyAvailability - недельное расписание мастера по дням и времени
+func WeeklyAvailability(db *gorm.DB, workerID uint) ([]models.WorkerAvailability, error) {
+	var weekly []models.WorkerAvailability
+	err := db.Where("worker_id = ?", workerID).Order("weekday, start").Find(&weekly).Error
+	return weekly, err
+}
+
+// ReplaceWeeklyAvailability заменяет недельное расписание мастера и его часовой пояс
+func ReplaceWeeklyAvailability(db *gorm.DB, workerID uint, timezone string, weekly []models.WorkerAvailability) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WorkerProfile{}).Where("user_id = ?", workerID).
+			Updates(map[string]interface{}{"timezone": timezone, "version": NextVersion()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("worker_id = ?", workerID).Delete(&models.WorkerAvailability{}).Error; err != nil {
+			return err
+		}
+		for i := range weekly {
+			weekly[i].ID = 0
+			weekly[i].WorkerID = workerID
+		}
+		if len(weekly) == 0 {
+			return nil
+		}
+		return tx.Create(&weekly).Error
+	})
+}
+
+// AvailabilityExceptions - исключения мастера, пересекающие даты [from, to] (нулевое время - без границы)
+func AvailabilityExceptions(db *gorm.DB, workerID uint, from, to time.Time) ([]models.AvailabilityException, error) {
+	query := db.Where("worker_id = ?", workerID)
+	if !from.IsZero() {
+		query = query.Where("date_to >= ?", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		query = query.Where("date_from <= ?", to.Format("2006-01-02"))
+	}
+	var exceptions []models.AvailabilityException
+	err := query.Order("date_from").Find(&exceptions).Error
+	return exceptions, err
+}
+
+// ActiveBookings - активные брони мастера, пересекающие [from, to)
+func ActiveBookings(db *gorm.DB, workerID uint, from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := db.Where("worker_id = ? AND status = ? AND starts_at < ? AND ends_at > ?", workerID, models.BookingActive, to, from).
+		Order("starts_at").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// WorkerWorkingTime - рабочее время мастера в [from, to) по расписанию и исключениям, без учёта броней
+func WorkerWorkingTime(db *gorm.DB, workerID uint, from, to time.Time) ([]availability.Interval, *time.Location, error) {
+	loc, err := WorkerLocation(db, workerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	weekly, err := WeeklyAvailability(db, workerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// даты исключений - по часовому поясу мастера
+	exceptions, err := AvailabilityExceptions(db, workerID, from.In(loc), to.In(loc))
+	if err != nil {
+		return nil, nil, err
+	}
+	return availability.Working(weekly, exceptions, from, to, loc), loc, nil
+}
+
+// Calendar - расписание мастера с исключениями и бронями за период: свободное время
+// для разных окон внутри периода считается без новых запросов
+type Calendar struct {
+	Location   *time.Location
+	Weekly     []models.WorkerAvailability
+	exceptions []models.AvailabilityException
+	busy       []availability.Interval
+}
+
+// WorkerCalendar - календарь мастера; исключения и активные брони загружаются за [from, to)
+func WorkerCalendar(db *gorm.DB, workerID uint, from, to time.Time) (*Calendar, error) {
+	calendars, err := WorkerCalendars(db, []uint{workerID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	calendar, ok := calendars[workerID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return calendar, nil
+}
+
+// WorkerCalendars - календари нескольких мастеров (по одному запросу на профили, расписания,
+// исключения и брони); мастера без профиля в результат не попадают
+func WorkerCalendars(db *gorm.DB, workerIDs []uint, from, to time.Time) (map[uint]*Calendar, error) {
+	var profiles []models.WorkerProfile
+	if err := db.Select("user_id, timezone").Where("user_id IN ?", workerIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	calendars := make(map[uint]*Calendar, len(profiles))
+	for _, p := range profiles {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		calendars[p.UserID] = &Calendar{Location: loc}
+	}
+
+	var weekly []models.WorkerAvailability
+	if err := db.Where("worker_id IN ?", workerIDs).Order("weekday, start").Find(&weekly).Error; err != nil {
+		return nil, err
+	}
+	for _, slot := range weekly {
+		if c, ok := calendars[slot.WorkerID]; ok {
+			c.Weekly = append(c.Weekly, slot)
+		}
+	}
+
+	// даты исключений - по часовому поясу мастера: берутся с запасом в сутки в обе стороны,
+	// лишние дни Working не учитывает
+	var exceptions []models.AvailabilityException
+	if err := db.Where("worker_id IN ? AND date_to >= ? AND date_from <= ?", workerIDs,
+		from.UTC().AddDate(0, 0, -1).Format("2006-01-02"), to.UTC().AddDate(0, 0, 1).Format("2006-01-02")).
+		Order("date_from").Find(&exceptions).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range exceptions {
+		if c, ok := calendars[e.WorkerID]; ok {
+			c.exceptions = append(c.exceptions, e)
+		}
+	}
+
+	var bookings []models.Booking
+	if err := db.Where("worker_id IN ? AND status = ? AND starts_at < ? AND ends_at > ?", workerIDs, models.BookingActive, to, from).
+		Order("starts_at").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		if c, ok := calendars[b.WorkerID]; ok {
+			c.busy = append(c.busy, availability.Interval{Start: b.StartsAt, End: b.EndsAt})
+		}
+	}
+	return calendars, nil
+}
+
+// Free - свободное время в [from, to): рабочее время минус брони
+func (c *Calendar) Free(from, to time.Time) []availability.Interval {
+	return availability.Subtract(availability.Working(c.Weekly, c.exceptions, from, to, c.Location), c.busy)
+}
+
+// WorkerFreeSlots - свободное время мастера в [from, to): рабочее время минус активные брони
+func WorkerFreeSlots(db *gorm.DB, workerID uint, from, to time.Time) ([]availability.Interval, *time.Location, error) {
+	calendar, err := WorkerCalendar(db, workerID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	free := calendar.Free(from, to)
+	for i := range free {
+		free[i].Start, free[i].End = free[i].Start.In(calendar.Location), free[i].End.In(calendar.Location)
+	}
+	return free, calendar.Location, nil
+}
+
+// ReserveSlot бронирует время мастера (booking.WorkerID, StartsAt, EndsAt). Вызывается в транзакции:
+// профиль мастера блокируется, чтобы одновременные брони не пересеклись.
+// Ошибки: *SlotConflict, ErrSlotUnavailable
+func ReserveSlot(tx *gorm.DB, booking *models.Booking) error {
+	var profile models.WorkerProfile
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("user_id = ?", booking.WorkerID).Take(&profile).Error; err != nil {
+		return err
+	}
+
+	overlapping, err := ActiveBookings(tx, booking.WorkerID, booking.StartsAt, booking.EndsAt)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) > 0 {
+		return &SlotConflict{Booking: overlapping[0]}
+	}
+
+	working, _, err := WorkerWorkingTime(tx, booking.WorkerID, booking.StartsAt, booking.EndsAt)
+	if err != nil {
+		return err
+	}
+	if !availability.Covers(working, availability.Interval{Start: booking.StartsAt, End: booking.EndsAt}) {
+		return ErrSlotUnavailable
+	}
+
+	booking.Status = models.BookingActive
+	return tx.Create(booking).Error
+}
+
+// CancelResponseBookings отменяет брони по отклику (отклик отозван или удалён)
+func CancelResponseBookings(db *gorm.DB, responseID uint) error {
+	return db.Model(&models.Booking{}).
+		Where("response_id = ? AND status = ?", responseID, models.BookingActive).
+		Update("status", models.BookingCancelled).Error
+}
+
+// CancelAdBookings отменяет брони по всем откликам объявления (объявление удалено или отменено)
+func CancelAdBookings(db *gorm.DB, adID uint) error {
+	return db.Model(&models.Booking{}).
+		Where("ad_id = ? AND status = ?", adID, models.BookingActive).
+		Update("status", models.BookingCancelled).Error
+}
+
+// MigrateWorkerBusy удаляет столбец is_busy: занятость мастера теперь вычисляется по броням и исключениям
+func MigrateWorkerBusy(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.WorkerProfile{}, "is_busy") {
+		return nil
+	}
+	return db.Migrator().DropColumn(&models.WorkerProfile{}, "is_busy")
+}
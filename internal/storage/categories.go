@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"go-api/internal/models"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Для категорий
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Slugify - латинский слаг из названия: "Ремонт труб" → "remont-trub"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case translit[r] != "":
+			b.WriteString(translit[r])
+			dash = false
+		case r == 'ъ' || r == 'ь':
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// ValidSlug - только латиница в нижнем регистре, цифры и одиночные дефисы
+func ValidSlug(slug string) bool {
+	return slug != "" && Slugify(slug) == slug
+}
+
+// UniqueCategorySlug подбирает свободный слаг (с учётом удалённых категорий): base, base-2, base-3...
+func UniqueCategorySlug(db *gorm.DB, name string) (string, error) {
+	base := Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Unscoped().Model(&models.Category{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// BackfillCategorySlugs проставляет слаги категориям без слага
+func BackfillCategorySlugs(db *gorm.DB) error {
+	var categories []models.Category
+	if err := db.Unscoped().Where("slug = '' OR slug IS NULL").Order("id").Find(&categories).Error; err != nil {
+		return err
+	}
+	for _, c := range categories {
+		slug, err := UniqueCategorySlug(db, c.Name)
+		if err != nil {
+			return err
+		}
+		if err := db.Unscoped().Model(&models.Category{}).Where("id = ?", c.ID).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CategoryByRef ищет категорию по ID, слагу или названию (без учёта регистра, включая переводы).
+// Названия оставлены для старых клиентов; стабильны только ID и слаг
+func CategoryByRef(db *gorm.DB, ref string) (*models.Category, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var category models.Category
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		// ID категории, влитой в другую, ведёт на целевую
+		id := ResolveRedirect(db, RedirectCategory, uint(id))
+		if err := db.First(&category, id).Error; err == nil {
+			return &category, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	// Слаг ищется и среди удалённых: слаг влитой категории тоже ведёт на целевую
+	result := db.Unscoped().Where("slug = ?", strings.ToLower(ref)).Limit(1).Find(&category)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		if !category.DeletedAt.Valid {
+			return &category, nil
+		}
+		if id := ResolveRedirect(db, RedirectCategory, category.ID); id != category.ID {
+			var target models.Category
+			if err := db.First(&target, id).Error; err == nil {
+				return &target, nil
+			}
+		}
+		category = models.Category{}
+	}
+
+	result = db.Where("name ILIKE ?", ref).Limit(1).Find(&category)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return &category, result.Error
+	}
+
+	result = db.Where("id IN (?)", db.Model(&models.CategoryTranslation{}).Select("category_id").Where("name ILIKE ?", ref)).
+		Limit(1).Find(&category)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return &category, result.Error
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// CategoryDescendantIDs - категории и все их подкатегории
+func CategoryDescendantIDs(db *gorm.DB, ids ...uint) ([]uint, error) {
+	var result []uint
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id IN ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, ids).Scan(&result).Error
+	return result, err
+}
+
+// CategoryAncestorIDs - категория и все её родители до корня
+func CategoryAncestorIDs(db *gorm.DB, id uint) ([]uint, error) {
+	var result []uint
+	err := db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN chain ch ON c.id = ch.parent_id
+		)
+		SELECT id FROM chain`, id).Scan(&result).Error
+	return result, err
+}
+
+// WorkerMatchesCategory - мастер указал категорию объявления или одну из её родительских
+// (мастер "Сантехника" видит объявления "Ремонт труб")
+func WorkerMatchesCategory(db *gorm.DB, workerID, categoryID uint) (bool, error) {
+	ancestors, err := CategoryAncestorIDs(db, categoryID)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = db.Model(&models.WorkerCategory{}).
+		Where("worker_id = ? AND category_id IN ?", workerID, ancestors).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func workerCategories(db *gorm.DB, workerID uint) []CategoryJSON {
+	var categories []models.Category
+	db.Table("categories c").
+		Joins("JOIN worker_categories wc ON c.id = wc.category_id").
+		Where("wc.worker_id = ? AND c.deleted_at IS NULL", workerID).
+		Order("c.sort_order, c.name").
+		Find(&categories)
+
+	catJSON := make([]CategoryJSON, len(categories))
+	for j, cat := range categories {
+		catJSON[j] = CategoryJSON{
+			ID:   cat.ID,
+			Name: cat.Name,
+			Slug: cat.Slug,
+		}
+	}
+	return catJSON
+}
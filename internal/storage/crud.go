@@ -2,6 +2,7 @@ package storage
 
 import (
 	"go-api/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -23,6 +24,7 @@ func UserById(db *gorm.DB, id uint) (*models.User, error) {
 type CategoryJSON struct {
 	ID   uint   `json:"id"`
 	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type WorkerResponse struct {
@@ -33,17 +35,21 @@ type WorkerResponse struct {
 	Phone       string  `json:"phone,omitempty"`
 	ExpYears    *int    `json:"exp_years,omitempty"`
 	Description *string `json:"description,omitempty"`
-	IsBusy      bool    `json:"is_busy"`
+	IsBusy      bool    `json:"is_busy"` // занят сейчас: исключение из расписания или бронь (см. workerBusySQL)
 	Location    string  `json:"location"`
 	Schedule    string  `json:"schedule"`
+	Timezone    string  `json:"timezone"`
 	// Только пользователи с have_worker_profile = true считаются "рабочими" во внешнем API
 	HaveWorkerProfile bool           `json:"have_worker_profile"`
 	Status            string         `json:"status"` // pending, approved, rejected
 	Categories        []CategoryJSON `json:"categories,omitempty" gorm:"-"`
+	// Последнее изменение пользователя или профиля (для Last-Modified)
+	UpdatedAt time.Time `json:"-"`
 }
 
 func ListApprovedWorkers(db *gorm.DB, limit, offset int) ([]WorkerResponse, int64, error) {
 	var total int64
+	now := time.Now()
 
 	db.Model(&models.User{}).
 		Joins("JOIN worker_profiles ON worker_profiles.user_id = users.id").
@@ -52,7 +58,8 @@ func ListApprovedWorkers(db *gorm.DB, limit, offset int) ([]WorkerResponse, int6
 
 	var workers []WorkerResponse
 	err := db.Table("users u").
-		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.is_busy, wp.location, wp.schedule, wp.have_worker_profile, wp.status").
+		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.location, wp.schedule, wp.timezone, wp.have_worker_profile, wp.status, "+
+			workerBusySQL, now, now, now).
 		Joins("JOIN worker_profiles wp ON u.id = wp.user_id").
 		Where("wp.have_worker_profile = ? AND wp.status = ?", true, "approved").
 		Order("u.id ASC").
@@ -65,20 +72,7 @@ func ListApprovedWorkers(db *gorm.DB, limit, offset int) ([]WorkerResponse, int6
 	}
 
 	for i := range workers {
-		var categories []models.Category
-		db.Table("categories c").
-			Joins("JOIN worker_categories wc ON c.id = wc.category_id").
-			Where("wc.worker_id = ?", workers[i].ID).
-			Find(&categories)
-
-		catJSON := make([]CategoryJSON, len(categories))
-		for j, cat := range categories {
-			catJSON[j] = CategoryJSON{
-				ID:   cat.ID,
-				Name: cat.Name,
-			}
-		}
-		workers[i].Categories = catJSON
+		workers[i].Categories = workerCategories(db, workers[i].ID)
 	}
 
 	return workers, total, nil
@@ -86,9 +80,11 @@ func ListApprovedWorkers(db *gorm.DB, limit, offset int) ([]WorkerResponse, int6
 
 func WorkerByID(db *gorm.DB, id uint) (*WorkerResponse, error) {
 	var worker WorkerResponse
+	now := time.Now()
 
 	err := db.Table("users u").
-		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.is_busy, wp.location, wp.schedule, wp.have_worker_profile, wp.status").
+		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.location, wp.schedule, wp.timezone, wp.have_worker_profile, wp.status, "+
+			"GREATEST(u.updated_at, wp.updated_at) as updated_at, "+workerBusySQL, now, now, now).
 		Joins("JOIN worker_profiles wp ON u.id = wp.user_id").
 		Where("u.id = ? AND wp.have_worker_profile = ? AND wp.status = ?", id, true, "approved").
 		Scan(&worker).Error
@@ -97,29 +93,18 @@ func WorkerByID(db *gorm.DB, id uint) (*WorkerResponse, error) {
 		return nil, err
 	}
 
-	var categories []models.Category
-	db.Table("categories c").
-		Joins("JOIN worker_categories wc ON c.id = wc.category_id").
-		Where("wc.worker_id = ?", worker.ID).
-		Find(&categories)
-
-	catJSON := make([]CategoryJSON, len(categories))
-	for j, cat := range categories {
-		catJSON[j] = CategoryJSON{
-			ID:   cat.ID,
-			Name: cat.Name,
-		}
-	}
-	worker.Categories = catJSON
+	worker.Categories = workerCategories(db, worker.ID)
 
 	return &worker, nil
 }
 
 func WorkerByUserID(db *gorm.DB, id uint) (*WorkerResponse, error) {
 	var worker WorkerResponse
+	now := time.Now()
 
 	err := db.Table("users u").
-		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.is_busy, wp.location, wp.schedule, wp.have_worker_profile, wp.status").
+		Select("u.id, u.id as worker_id, u.name, u.email, u.phone, wp.exp_years, wp.description, wp.location, wp.schedule, wp.timezone, wp.have_worker_profile, wp.status, "+
+			workerBusySQL, now, now, now).
 		Joins("JOIN worker_profiles wp ON u.id = wp.user_id").
 		Where("u.id = ?", id).
 		Scan(&worker).Error
@@ -128,20 +113,41 @@ func WorkerByUserID(db *gorm.DB, id uint) (*WorkerResponse, error) {
 		return nil, err
 	}
 
-	var categories []models.Category
-	db.Table("categories c").
-		Joins("JOIN worker_categories wc ON c.id = wc.category_id").
-		Where("wc.worker_id = ?", worker.ID).
-		Find(&categories)
-
-	catJSON := make([]CategoryJSON, len(categories))
-	for j, cat := range categories {
-		catJSON[j] = CategoryJSON{
-			ID:   cat.ID,
-			Name: cat.Name,
-		}
-	}
-	worker.Categories = catJSON
+	worker.Categories = workerCategories(db, worker.ID)
 
 	return &worker, nil
 }
+
+// Для ограничений аккаунтов
+
+// Области действия ограничения. login блокирует всё
+const (
+	SuspensionScopeAds       = "ads"
+	SuspensionScopeResponses = "responses"
+	SuspensionScopeLogin     = "login"
+)
+
+// ActiveSuspension возвращает действующее ограничение пользователя для scope
+// (или полный бан). Истёкшие ограничения перестают действовать автоматически
+func ActiveSuspension(db *gorm.DB, userID uint, scope string) (*models.Suspension, error) {
+	var suspension models.Suspension
+	now := time.Now()
+	result := db.Where("user_id = ? AND scope IN ? AND lifted_at IS NULL AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)",
+		userID, []string{scope, SuspensionScopeLogin}, now, now).
+		Order("ends_at DESC NULLS FIRST").
+		Limit(1).
+		Find(&suspension)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &suspension, nil
+}
+
+func ActiveSuspensions(db *gorm.DB, userID uint) ([]models.Suspension, error) {
+	var suspensions []models.Suspension
+	now := time.Now()
+	err := db.Where("user_id = ? AND lifted_at IS NULL AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", userID, now, now).
+		Order("starts_at DESC").
+		Find(&suspensions).Error
+	return suspensions, err
+}
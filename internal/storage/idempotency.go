@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"go-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Для ключей идемпотентности
+
+const (
+	IdempotencyTTL = 24 * time.Hour // сколько хранится ответ на запрос с ключом
+
+	// запрос, который не завершился за это время (упал процесс), считается брошенным
+	// и ключ можно занять заново
+	idempotencyLockTimeout = time.Minute
+)
+
+// ReserveIdempotencyKey занимает ключ под новый запрос. Если ключ уже занят - возвращает
+// существующую запись и reserved=false: по ней решается, повторить ответ или вернуть конфликт
+func ReserveIdempotencyKey(db *gorm.DB, scope, key, fingerprint string) (record *models.IdempotencyKey, reserved bool, err error) {
+	now := time.Now()
+
+	// истёкшая или брошенная запись не мешает занять ключ
+	if err := db.Where("scope = ? AND key = ?", scope, key).
+		Where("expires_at < ? OR (status_code = 0 AND created_at < ?)", now, now.Add(-idempotencyLockTimeout)).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record = &models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(IdempotencyTTL),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("scope = ? AND key = ?", scope, key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ на запрос, занявший ключ
+func CompleteIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey, status int, contentType string, body []byte) error {
+	return db.Model(&models.IdempotencyKey{}).
+		Where("scope = ? AND key = ?", record.Scope, record.Key).
+		Updates(map[string]interface{}{"status_code": status, "content_type": contentType, "body": body}).Error
+}
+
+// ReleaseIdempotencyKey освобождает ключ (запрос не выполнен, его можно повторить с тем же ключом)
+func ReleaseIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey) error {
+	return db.Where("scope = ? AND key = ?", record.Scope, record.Key).Delete(&models.IdempotencyKey{}).Error
+}
+
+// PurgeIdempotencyKeys удаляет истёкшие ключи
+func PurgeIdempotencyKeys(db *gorm.DB) (int64, error) {
+	result := db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Данные для подбора объявлений и мастеров (internal/matching)
+
+// maxCategoryDepth ограничивает обход дерева категорий
+const maxCategoryDepth = 10
+
+// CategoryDistances - категории ids и все их подкатегории с расстоянием до ближайшей из ids
+// (0 - сама категория, 1 - дочерняя и т.д.)
+func CategoryDistances(db *gorm.DB, ids ...uint) (map[uint]int, error) {
+	var rows []struct {
+		ID    uint
+		Depth int
+	}
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, 0 AS depth FROM categories WHERE id IN ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, t.depth + 1 FROM categories c JOIN tree t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL AND t.depth < ?
+		)
+		SELECT id, MIN(depth) AS depth FROM tree GROUP BY id`, ids, maxCategoryDepth).Scan(&rows).Error
+	distances := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		distances[row.ID] = row.Depth
+	}
+	return distances, err
+}
+
+// CategoryAncestorDistances - категория и все её родители с расстоянием до неё (0 - сама категория)
+func CategoryAncestorDistances(db *gorm.DB, id uint) (map[uint]int, error) {
+	var rows []struct {
+		ID    uint
+		Depth int
+	}
+	err := db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, ch.depth + 1 FROM categories c JOIN chain ch ON c.id = ch.parent_id
+			WHERE ch.depth < ?
+		)
+		SELECT id, MIN(depth) AS depth FROM chain GROUP BY id`, id, maxCategoryDepth).Scan(&rows).Error
+	distances := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		distances[row.ID] = row.Depth
+	}
+	return distances, err
+}
+
+// WorkerStats - отзывы о мастере и решения владельцев объявлений по его откликам
+type WorkerStats struct {
+	RatingAvg   float64
+	RatingCount int
+	Accepted    int
+	Rejected    int
+}
+
+// WorkerStatsByID - статистика мастеров по их user_id (мастера без отзывов и откликов - нулевая)
+func WorkerStatsByID(db *gorm.DB, workerIDs []uint) (map[uint]WorkerStats, error) {
+	stats := make(map[uint]WorkerStats, len(workerIDs))
+
+	var ratings []struct {
+		WorkerID uint
+		Avg      float64
+		Count    int
+	}
+	if err := db.Model(&models.Review{}).
+		Select("worker_id, AVG(rating) as avg, COUNT(*) as count").
+		Where("worker_id IN ?", workerIDs).
+		Group("worker_id").
+		Scan(&ratings).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range ratings {
+		s := stats[r.WorkerID]
+		s.RatingAvg, s.RatingCount = r.Avg, r.Count
+		stats[r.WorkerID] = s
+	}
+
+	var decisions []struct {
+		WorkerID uint
+		Accepted int
+		Rejected int
+	}
+	if err := db.Model(&models.Response{}).
+		Select("worker_id, COUNT(*) FILTER (WHERE status = ?) as accepted, COUNT(*) FILTER (WHERE status = ?) as rejected",
+			models.ResponseStatusAccepted, models.ResponseStatusRejected).
+		Where("worker_id IN ?", workerIDs).
+		Group("worker_id").
+		Scan(&decisions).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range decisions {
+		s := stats[d.WorkerID]
+		s.Accepted, s.Rejected = d.Accepted, d.Rejected
+		stats[d.WorkerID] = s
+	}
+	return stats, nil
+}
+
+// PriceKey - мастер, категория и единица цены
+type PriceKey struct {
+	WorkerID    uint
+	CategoryID  uint
+	PriceUnitID uint
+}
+
+// TypicalPrices - медианы цен мастеров в категориях по их откликам: согласованная цена,
+// а если отклик ещё не принят - последняя предложенная
+func TypicalPrices(db *gorm.DB, workerIDs, categoryIDs []uint) (map[PriceKey]float64, error) {
+	var rows []struct {
+		PriceKey
+		Median float64
+	}
+	err := db.Table("responses r").
+		Select("r.worker_id, a.category_id, a.price_unit_id, "+
+			"percentile_cont(0.5) WITHIN GROUP (ORDER BY COALESCE(r.agreed_price, r.proposed_price)) as median").
+		Joins("JOIN ads a ON a.id = r.ad_id").
+		Where("r.worker_id IN ? AND a.category_id IN ? AND r.deleted_at IS NULL", workerIDs, categoryIDs).
+		Where("COALESCE(r.agreed_price, r.proposed_price) IS NOT NULL").
+		Group("r.worker_id, a.category_id, a.price_unit_id").
+		Scan(&rows).Error
+	prices := make(map[PriceKey]float64, len(rows))
+	for _, row := range rows {
+		prices[row.PriceKey] = row.Median
+	}
+	return prices, err
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"go-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Виды уведомлений
+const (
+	NotifyAdExpiring = "ad_expiring" // срок публикации скоро истечёт
+	NotifyAdExpired  = "ad_expired"  // срок публикации истёк, объявление можно опубликовать повторно
+
+	NotifyResponseAccepted = "response_accepted" // мастеру: отклик принят (и, возможно, забронировано время)
+	NotifyResponseRejected = "response_rejected" // мастеру: отклик отклонён
+
+	NotifyOfferReceived = "offer_received" // другой стороне: новое предложение цены по отклику
+	NotifyOfferAccepted = "offer_accepted" // клиенту: мастер принял его предложение, отклик принят
+
+	NotifyInvitationReceived = "invitation_received" // мастеру: приглашение откликнуться на объявление
+	NotifyInvitationAccepted = "invitation_accepted" // клиенту: мастер принял приглашение и откликнулся
+	NotifyInvitationDeclined = "invitation_declined" // клиенту: мастер отказался от приглашения
+)
+
+// Notify создаёт уведомление пользователю
+func Notify(db *gorm.DB, userID uint, kind, message string, adID *uint) error {
+	return db.Create(&models.Notification{
+		UserID:    userID,
+		Kind:      kind,
+		Message:   message,
+		AdID:      adID,
+		CreatedAt: time.Now(),
+	}).Error
+}
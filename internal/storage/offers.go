@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Предложения цены по откликам
+
+// OfferHistory - все предложения по отклику в порядке создания
+func OfferHistory(db *gorm.DB, responseID uint) ([]models.Offer, error) {
+	var offers []models.Offer
+	err := db.Where("response_id = ?", responseID).Order("created_at, id").Find(&offers).Error
+	return offers, err
+}
+
+// OpenOffer - открытое (последнее) предложение по отклику или nil
+func OpenOffer(db *gorm.DB, responseID uint) (*models.Offer, error) {
+	var offers []models.Offer
+	if err := db.Where("response_id = ? AND status = ?", responseID, models.OfferOpen).Limit(1).Find(&offers).Error; err != nil {
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, nil
+	}
+	return &offers[0], nil
+}
+
+// PlaceOffer добавляет предложение: открытое до него становится superseded, а его цена -
+// текущей предложенной ценой отклика. Вызывается в транзакции
+func PlaceOffer(tx *gorm.DB, offer *models.Offer) error {
+	if err := tx.Model(&models.Offer{}).
+		Where("response_id = ? AND status = ?", offer.ResponseID, models.OfferOpen).
+		Update("status", models.OfferSuperseded).Error; err != nil {
+		return err
+	}
+	offer.Status = models.OfferOpen
+	if err := tx.Create(offer).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Response{}).Where("id = ?", offer.ResponseID).
+		Update("proposed_price", offer.Price).Error
+}
+
+// CloseOffers закрывает открытое предложение по отклику (отклик отклонён, отозван или удалён)
+func CloseOffers(db *gorm.DB, responseID uint) error {
+	return db.Model(&models.Offer{}).
+		Where("response_id = ? AND status = ?", responseID, models.OfferOpen).
+		Update("status", models.OfferClosed).Error
+}
+
+// CloseAdOffers закрывает открытые предложения по всем откликам объявления (объявление удалено или закрыто)
+func CloseAdOffers(db *gorm.DB, adID uint) error {
+	return db.Model(&models.Offer{}).
+		Where("status = ? AND response_id IN (?)", models.OfferOpen,
+			db.Unscoped().Model(&models.Response{}).Select("id").Where("ad_id = ?", adID)).
+		Update("status", models.OfferClosed).Error
+}
+
+// MigrateOffers переносит предложенную цену откликов, созданных до появления торга, в первое
+// предложение мастера; у принятых откликов эта цена становится согласованной
+func MigrateOffers(db *gorm.DB) error {
+	if err := db.Exec(`INSERT INTO offers (response_id, author_id, party, price, price_unit_id, status, created_at)
+		SELECT r.id, r.worker_id, ?, r.proposed_price, a.price_unit_id,
+			CASE r.status WHEN ? THEN ? WHEN ? THEN ? ELSE ? END, r.created_at
+		FROM responses r JOIN ads a ON a.id = r.ad_id
+		WHERE r.proposed_price IS NOT NULL AND r.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM offers o WHERE o.response_id = r.id)`,
+		models.OfferPartyWorker,
+		models.ResponseStatusPending, models.OfferOpen,
+		models.ResponseStatusAccepted, models.OfferAccepted,
+		models.OfferClosed).Error; err != nil {
+		return err
+	}
+	return db.Exec(`UPDATE responses SET agreed_price = proposed_price
+		WHERE status = ? AND agreed_price IS NULL AND proposed_price IS NOT NULL`,
+		models.ResponseStatusAccepted).Error
+}
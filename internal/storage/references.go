@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Для справочников
+
+// Сущности, для которых хранятся перенаправления после слияния
+const (
+	RedirectCategory  = "category"
+	RedirectPriceUnit = "price_unit"
+)
+
+// ResolveRedirect возвращает актуальный ID записи справочника: если id был влит
+// в другую запись - ID целевой, иначе сам id
+func ResolveRedirect(db *gorm.DB, entity string, id uint) uint {
+	var redirect models.ReferenceRedirect
+	result := db.Where("entity = ? AND from_id = ?", entity, id).Limit(1).Find(&redirect)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return id
+	}
+	return redirect.ToID
+}
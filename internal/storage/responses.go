@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DeleteResponse мягко удаляет отклик: торг по нему закрывается, забронированное время мастера освобождается
+func DeleteResponse(db *gorm.DB, response *models.Response) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(response).Error; err != nil {
+			return err
+		}
+		if err := CloseOffers(tx, response.ID); err != nil {
+			return err
+		}
+		return CancelResponseBookings(tx, response.ID)
+	})
+}
+
+// DeduplicateResponses мягко удаляет повторные отклики мастера на одно объявление, оставляя первый
+func DeduplicateResponses(db *gorm.DB) error {
+	return db.Exec(`UPDATE responses SET deleted_at = NOW()
+		WHERE deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM responses first
+			WHERE first.ad_id = responses.ad_id AND first.worker_id = responses.worker_id
+				AND first.deleted_at IS NULL AND first.id < responses.id
+		)`).Error
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Transition - условная смена статуса записи с версией (объявления, профили мастеров)
+// или без неё (статус модерации отклика)
+type Transition struct {
+	Model       interface{}            // &models.Ad{}, &models.WorkerProfile{} или &models.Response{}
+	Column      string                 // колонка статуса, по умолчанию status
+	Unversioned bool                   // у записи нет версии: изменение условное по прежнему статусу
+	Where       string                 // условие выбора записи, например "id = ? AND user_id = ?"
+	Args        []interface{}          // аргументы Where
+	From        []string               // статусы, из которых переход допустим
+	To          string                 // новый статус
+	Version     uint                   // версия из If-Match, 0 - любая
+	Updates     map[string]interface{} // поля, которые меняются вместе со статусом
+}
+
+// StatusConflict - переход невозможен из текущего статуса записи
+type StatusConflict struct {
+	Status  string
+	Version uint
+}
+
+func (e *StatusConflict) Error() string {
+	return fmt.Sprintf("status is %s", e.Status)
+}
+
+// VersionMismatch - запись изменилась после чтения клиентом (If-Match не совпал)
+type VersionMismatch struct {
+	Version uint
+}
+
+func (e *VersionMismatch) Error() string {
+	return fmt.Sprintf("current version is %d", e.Version)
+}
+
+type statusState struct {
+	Status  string
+	Version uint
+}
+
+// TransitionStatus меняет статус, только если запись сейчас в одном из t.From ("одобрить, только
+// если ещё pending"), и увеличивает версию. Изменение условное по версии (Unversioned - по прежнему
+// статусу): если запись изменили между чтением и записью - *StatusConflict с актуальным статусом.
+// Возвращает прежний статус.
+// Ошибки: gorm.ErrRecordNotFound, *VersionMismatch, *StatusConflict
+func TransitionStatus(db *gorm.DB, t Transition) (prev string, err error) {
+	column := t.Column
+	if column == "" {
+		column = "status"
+	}
+	state := func(dest *statusState) error {
+		fields := column + " AS status"
+		if !t.Unversioned {
+			fields += ", version"
+		}
+		return db.Model(t.Model).Select(fields).Where(t.Where, t.Args...).Take(dest).Error
+	}
+
+	var cur statusState
+	if err := state(&cur); err != nil {
+		return "", err
+	}
+	if t.Version != 0 && cur.Version != t.Version {
+		return "", &VersionMismatch{Version: cur.Version}
+	}
+	if !slices.Contains(t.From, cur.Status) {
+		return "", &StatusConflict{Status: cur.Status, Version: cur.Version}
+	}
+
+	updates := map[string]interface{}{column: t.To}
+	guard := db.Model(t.Model).Where(t.Where, t.Args...)
+	if t.Unversioned {
+		guard = guard.Where(column+" = ?", cur.Status)
+	} else {
+		updates["version"] = NextVersion()
+		guard = guard.Where("version = ?", cur.Version)
+	}
+	for k, v := range t.Updates {
+		updates[k] = v
+	}
+	result := guard.Updates(updates)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		// запись изменили (или удалили) после чтения - возвращаем актуальное состояние
+		var latest statusState
+		if err := state(&latest); err != nil {
+			return "", err
+		}
+		return "", &StatusConflict{Status: latest.Status, Version: latest.Version}
+	}
+	return cur.Status, nil
+}
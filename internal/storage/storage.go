@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go-api/internal/config"
 	"go-api/internal/models"
+	"go-api/internal/tracing"
 	"log"
 	"log/slog"
 	"os"
@@ -41,6 +42,11 @@ func NewDB(cfg config.DB, basicLogger *slog.Logger) (*Postgres, error) {
 		return nil, fmt.Errorf("postgres connect failed: %w", err)
 	}
 
+	// спаны OpenTelemetry на каждый запрос (если у контекста есть родительский спан)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("gorm tracing plugin: %w", err)
+	}
+
 	sqlDB, err := db.DB() //получение пула соединений
 
 	if err != nil {
@@ -51,7 +57,7 @@ func NewDB(cfg config.DB, basicLogger *slog.Logger) (*Postgres, error) {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)       //Максимальное количество открытых соединений одновременно
 	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime) //Максимальное время жизни соединения
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.PingTimeout)
 	defer cancel()
 	if err := sqlDB.PingContext(ctx); err != nil {
 		return nil, fmt.Errorf("postgres ping failed: %w", err)
@@ -66,7 +72,10 @@ func NewDB(cfg config.DB, basicLogger *slog.Logger) (*Postgres, error) {
 	db.AutoMigrate(
 		&models.Role{},
 		&models.Category{},
+		&models.CategoryTranslation{},
 		&models.PriceUnit{},
+		&models.ReferenceMerge{},
+		&models.ReferenceRedirect{},
 
 		&models.User{},
 		&models.WorkerProfile{},
@@ -74,11 +83,78 @@ func NewDB(cfg config.DB, basicLogger *slog.Logger) (*Postgres, error) {
 		&models.Ad{},
 		&models.Review{},
 		&models.Response{},
+		&models.Offer{},
+		&models.Invitation{},
 
 		&models.WorkerCategory{},
 		&models.BlackList{},
+		&models.ModerationDecision{},
+		&models.Report{},
+		&models.Suspension{},
+		&models.AuditLog{},
+		&models.StatsRollup{},
+		&models.AdFirstResponse{},
+		&models.IdempotencyKey{},
+		&models.Notification{},
+		&models.WorkerAvailability{},
+		&models.AvailabilityException{},
+		&models.Booking{},
 	)
 
+	// Одобренные объявления (approved) до появления жизненного цикла становятся активными
+	if err := MigrateAdStatuses(db); err != nil {
+		basicLogger.Error("ad statuses migration failed", slog.String("error", err.Error()))
+	}
+	// Цена и строка schedule до появления бюджета и желаемых дат
+	if err := MigrateAdContent(db); err != nil {
+		basicLogger.Error("ad content migration failed", slog.String("error", err.Error()))
+	}
+	// Занятость мастера больше не хранится, а вычисляется
+	if err := MigrateWorkerBusy(db); err != nil {
+		basicLogger.Error("worker busy migration failed", slog.String("error", err.Error()))
+	}
+	// Предложенная цена откликов до появления торга становится первым предложением мастера
+	if err := MigrateOffers(db); err != nil {
+		basicLogger.Error("offers migration failed", slog.String("error", err.Error()))
+	}
+
+	// Журнал аудита только на добавление: UPDATE и DELETE запрещены на уровне БД
+	for _, stmt := range []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`,
+		`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+		FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			basicLogger.Error("audit log trigger setup failed", slog.String("error", err.Error()))
+		}
+	}
+
+	// Слаги для категорий, созданных до их появления, затем уникальный индекс
+	if err := BackfillCategorySlugs(db); err != nil {
+		basicLogger.Error("category slugs backfill failed", slog.String("error", err.Error()))
+	} else if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug)`).Error; err != nil {
+		basicLogger.Error("category slug index setup failed", slog.String("error", err.Error()))
+	}
+
+	// Один отклик мастера на объявление: дубликаты, созданные до индекса, мягко удаляются (остаётся первый)
+	if err := DeduplicateResponses(db); err != nil {
+		basicLogger.Error("responses deduplication failed", slog.String("error", err.Error()))
+	} else if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_responses_ad_worker
+		ON responses (ad_id, worker_id) WHERE deleted_at IS NULL`).Error; err != nil {
+		basicLogger.Error("responses unique index setup failed", slog.String("error", err.Error()))
+	}
+
+	// Открыто только последнее предложение по отклику
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_offers_open
+		ON offers (response_id) WHERE status = 'open'`).Error; err != nil {
+		basicLogger.Error("offers open index setup failed", slog.String("error", err.Error()))
+	}
+
 	return &Postgres{db: db}, nil
 }
 
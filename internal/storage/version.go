@@ -0,0 +1,11 @@
+package storage
+
+import "gorm.io/gorm"
+
+// Для оптимистичных блокировок
+
+// NextVersion - значение поля version в Update/Updates. Любое изменение объявления или профиля
+// мастера увеличивает версию, и клиент с устаревшим If-Match получает 412 вместо тихой перезаписи
+func NextVersion() interface{} {
+	return gorm.Expr("version + 1")
+}
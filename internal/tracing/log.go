@@ -0,0 +1,17 @@
+package tracing
+
+import (
+	"log/slog"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Scope привязывает db и logger к запросу: запросы GORM становятся дочерними
+// спанами запроса (и отменяются вместе с ним), а в логи попадает trace_id
+func Scope(r *http.Request, db *gorm.DB, logger *slog.Logger) (*gorm.DB, *slog.Logger) {
+	if id := TraceID(r.Context()); id != "" {
+		logger = logger.With(slog.String("trace_id", id))
+	}
+	return db.WithContext(r.Context()), logger
+}